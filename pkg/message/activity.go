@@ -0,0 +1,180 @@
+package message
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Time buckets activity is counted in
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+	BucketYear  = "year"
+)
+
+// bucketLayouts are the key formats of each bucket, weeks are keyed by
+// the date of their Monday so every key sorts chronologically
+var bucketLayouts = map[string]string{
+	BucketDay:   "2006-01-02",
+	BucketWeek:  "2006-01-02",
+	BucketMonth: "2006-01",
+	BucketYear:  "2006",
+}
+
+// Activity counts messages by when they were sent, DayOfWeek and the
+// rows of HourByWeekday are indexed by time.Weekday
+type Activity struct {
+	HourOfDay     [24]int
+	DayOfWeek     [7]int
+	HourByWeekday [7][24]int
+	Days          map[string]int
+	Weeks         map[string]int
+	Months        map[string]int
+	Years         map[string]int
+}
+
+// SortedActivity contains the activity with every bucket in chronological order
+type SortedActivity struct {
+	HourOfDay     [24]int
+	DayOfWeek     [7]int
+	HourByWeekday [7][24]int
+	Days          StringFreqs
+	Weeks         StringFreqs
+	Months        StringFreqs
+	Years         StringFreqs
+}
+
+func newActivity() *Activity {
+	return &Activity{
+		Days:   make(map[string]int),
+		Weeks:  make(map[string]int),
+		Months: make(map[string]int),
+		Years:  make(map[string]int),
+	}
+}
+
+// add counts a message sent at t
+func (a *Activity) add(t time.Time) {
+	a.HourOfDay[t.Hour()]++
+	a.DayOfWeek[t.Weekday()]++
+	a.HourByWeekday[t.Weekday()][t.Hour()]++
+	a.Days[BucketKey(t, BucketDay)]++
+	a.Weeks[BucketKey(t, BucketWeek)]++
+	a.Months[BucketKey(t, BucketMonth)]++
+	a.Years[BucketKey(t, BucketYear)]++
+}
+
+// Bucket returns the counts of the named bucket
+func (a *Activity) Bucket(bucket string) map[string]int {
+	switch bucket {
+	case BucketDay:
+		return a.Days
+	case BucketWeek:
+		return a.Weeks
+	case BucketMonth:
+		return a.Months
+	case BucketYear:
+		return a.Years
+	}
+
+	return nil
+}
+
+func sortActivity(a *Activity) SortedActivity {
+	return SortedActivity{
+		HourOfDay:     a.HourOfDay,
+		DayOfWeek:     a.DayOfWeek,
+		HourByWeekday: a.HourByWeekday,
+		Days:          MapToChronologicalStringFreqs(a.Days),
+		Weeks:         MapToChronologicalStringFreqs(a.Weeks),
+		Months:        MapToChronologicalStringFreqs(a.Months),
+		Years:         MapToChronologicalStringFreqs(a.Years),
+	}
+}
+
+// Bucket returns the sorted counts of the named bucket
+func (s SortedActivity) Bucket(bucket string) StringFreqs {
+	switch bucket {
+	case BucketDay:
+		return s.Days
+	case BucketWeek:
+		return s.Weeks
+	case BucketMonth:
+		return s.Months
+	case BucketYear:
+		return s.Years
+	}
+
+	return StringFreqs{}
+}
+
+// BucketKey returns the key of the bucket t falls in
+func BucketKey(t time.Time, bucket string) string {
+	return BucketStart(t, bucket).Format(bucketLayouts[bucket])
+}
+
+// BucketStart returns the start of the bucket t falls in
+func BucketStart(t time.Time, bucket string) time.Time {
+	y, m, d := t.Date()
+	switch bucket {
+	case BucketWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case BucketMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	case BucketYear:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, t.Location())
+	}
+
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// NextBucket returns the start of the bucket following the one starting at t
+func NextBucket(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	case BucketYear:
+		return t.AddDate(1, 0, 0)
+	}
+
+	return t.AddDate(0, 0, 1)
+}
+
+// ParseBucketKey returns the start of the bucket with the key
+func ParseBucketKey(key string, bucket string, loc *time.Location) (time.Time, error) {
+	layout, ok := bucketLayouts[bucket]
+	if !ok {
+		return time.Time{}, errors.Errorf("unknown bucket %s", bucket)
+	}
+
+	return time.ParseInLocation(layout, key, loc)
+}
+
+// MessageTime returns when the message was sent in the location
+func MessageTime(m Message, loc *time.Location) time.Time {
+	return time.Unix(0, m.TimestampMs*int64(time.Millisecond)).In(loc)
+}
+
+// MapToChronologicalStringFreqs generates the StringFreqs of time bucket
+// counts ordered by bucket
+func MapToChronologicalStringFreqs(m map[string]int) StringFreqs {
+	sfs := StringFreqs{}
+
+	for k, v := range m {
+		sfs = append(sfs, StringFreq{
+			Value: k,
+			Freq:  v,
+		})
+	}
+
+	sort.Slice(sfs, func(i, j int) bool {
+		return sfs[i].Value < sfs[j].Value
+	})
+	return sfs
+}
@@ -0,0 +1,68 @@
+package message
+
+import "time"
+
+// Calls counts the calls someone started, Seconds adds up the duration
+// of the calls that were answered
+type Calls struct {
+	Count    int
+	Missed   int
+	Seconds  int64
+	Activity *Activity
+}
+
+// SortedCalls contains the call statistics, durations are in seconds
+type SortedCalls struct {
+	Count          int
+	Missed         int
+	TotalSeconds   int64
+	AverageSeconds float64
+	MissedRate     float64
+	Activity       SortedActivity
+}
+
+func newCalls() *Calls {
+	return &Calls{
+		Activity: newActivity(),
+	}
+}
+
+// add counts the call m records, started at sent
+func (c *Calls) add(m Message, sent time.Time) {
+	c.Count++
+	c.Activity.add(sent)
+	if m.IsMissedCall() {
+		c.Missed++
+		return
+	}
+	c.Seconds += m.CallDuration
+}
+
+// analyzeCall credits a call to whoever started it
+func (a *Analysis) analyzeCall(m Message, sent time.Time) {
+	if m.Type != TypeCall {
+		return
+	}
+
+	a.Calls.add(m, sent)
+	a.participant(m.SenderName).Calls.add(m, sent)
+}
+
+func sortCalls(c *Calls) SortedCalls {
+	sorted := SortedCalls{
+		Count:        c.Count,
+		Missed:       c.Missed,
+		TotalSeconds: c.Seconds,
+		Activity:     sortActivity(c.Activity),
+	}
+	if c.Count == 0 {
+		return sorted
+	}
+
+	sorted.MissedRate = float64(c.Missed) / float64(c.Count)
+	if answered := c.Count - c.Missed; answered > 0 {
+		sorted.AverageSeconds = float64(c.Seconds) / float64(answered)
+	}
+
+	return sorted
+}
@@ -0,0 +1,69 @@
+package message
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// distinctivePrior is the total weight of the prior, the group's word
+	// frequencies, that every participant's counts are smoothed with
+	distinctivePrior = 1000.0
+	// distinctiveKept is how many distinctive words are kept per participant
+	distinctiveKept = 100
+)
+
+// WordScore is a word and how distinctive it is of someone
+type WordScore struct {
+	Word  string
+	Score float64
+}
+
+// distinctiveWords ranks the participant's words by the z-score of their
+// log-odds against everyone else's, using the group's word frequencies as
+// an informative Dirichlet prior. Common words get a score near zero for
+// everyone and rare words are not inflated by small counts, only words the
+// participant uses more than the rest are kept.
+func distinctiveWords(a Analysis, name string) []WordScore {
+	words := a.ParticipantAnalyses[name].Words
+
+	total := 0
+	for _, count := range a.Words {
+		total += count
+	}
+	own := 0
+	for _, count := range words {
+		own += count
+	}
+	rest := total - own
+	if total == 0 || own == 0 {
+		return []WordScore{}
+	}
+
+	scores := []WordScore{}
+	for word, count := range words {
+		prior := distinctivePrior * float64(a.Words[word]) / float64(total)
+		yi := float64(count)
+		yj := float64(a.Words[word] - count)
+
+		delta := math.Log((yi+prior)/(float64(own)+distinctivePrior-yi-prior)) -
+			math.Log((yj+prior)/(float64(rest)+distinctivePrior-yj-prior))
+		variance := 1/(yi+prior) + 1/(yj+prior)
+		score := delta / math.Sqrt(variance)
+		if score > 0 {
+			scores = append(scores, WordScore{Word: word, Score: score})
+		}
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Word < scores[j].Word
+	})
+	if len(scores) > distinctiveKept {
+		scores = scores[:distinctiveKept]
+	}
+
+	return scores
+}
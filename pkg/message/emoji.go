@@ -0,0 +1,150 @@
+package message
+
+import (
+	"unicode/utf8"
+)
+
+const (
+	zeroWidthJoiner    = '\u200D'
+	variationSelector  = '\uFE0F'
+	combiningKeycap    = '\u20E3'
+	tagCancel          = '\U000E007F'
+	regionalIndicatorA = '\U0001F1E6'
+	regionalIndicatorZ = '\U0001F1FF'
+)
+
+// ExtractEmojis returns every emoji in s as a whole grapheme, so skin tone
+// modifiers, keycaps, flags and zero width joiner sequences such as
+// families stay one emoji
+func ExtractEmojis(s string) []string {
+	emojis := []string{}
+	for i := 0; i < len(s); {
+		end := emojiEnd(s, i)
+		if end > i {
+			emojis = append(emojis, s[i:end])
+			i = end
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+
+	return emojis
+}
+
+// emojiEnd returns the end of the emoji starting at i, or i if there
+// is none
+func emojiEnd(s string, i int) int {
+	r, size := utf8.DecodeRuneInString(s[i:])
+	next, nextSize := utf8.DecodeRuneInString(s[i+size:])
+
+	// flags are a pair of regional indicators
+	if isRegionalIndicator(r) {
+		if isRegionalIndicator(next) {
+			return i + size + nextSize
+		}
+		return i
+	}
+
+	// keycaps are a digit, # or * with an optional variation selector
+	if r == '#' || r == '*' || (r >= '0' && r <= '9') {
+		j := i + size
+		if next == variationSelector {
+			j += nextSize
+		}
+		if c, cSize := utf8.DecodeRuneInString(s[j:]); c == combiningKeycap {
+			return j + cSize
+		}
+		return i
+	}
+
+	if !isEmojiBase(r) && !(isTextEmoji(r) && next == variationSelector) {
+		return i
+	}
+
+	j := i + size
+	for {
+		j = emojiModifiersEnd(s, j)
+
+		c, cSize := utf8.DecodeRuneInString(s[j:])
+		if c != zeroWidthJoiner {
+			return j
+		}
+		joined, joinedSize := utf8.DecodeRuneInString(s[j+cSize:])
+		if !isEmojiBase(joined) && !isTextEmoji(joined) {
+			return j
+		}
+		j += cSize + joinedSize
+	}
+}
+
+// emojiModifiersEnd skips the variation selector, skin tone and tag
+// sequence that may follow an emoji
+func emojiModifiersEnd(s string, j int) int {
+	for j < len(s) {
+		c, cSize := utf8.DecodeRuneInString(s[j:])
+		switch {
+		case c == variationSelector, isSkinTone(c):
+			j += cSize
+		case c >= '\U000E0020' && c <= tagCancel:
+			j += cSize
+			if c == tagCancel {
+				return j
+			}
+		default:
+			return j
+		}
+	}
+
+	return j
+}
+
+// isEmojiBase reports whether r is drawn as an emoji on its own
+func isEmojiBase(r rune) bool {
+	switch {
+	case r >= '\U0001F000' && r <= '\U0001FAFF':
+		return !isSkinTone(r) && !isRegionalIndicator(r)
+	case r >= '\u2600' && r <= '\u27BF',
+		r == '\u231A', r == '\u231B', r == '\u2328', r == '\u23CF',
+		r >= '\u23E9' && r <= '\u23F3',
+		r >= '\u23F8' && r <= '\u23FA',
+		r >= '\u2B05' && r <= '\u2B07',
+		r == '\u2B1B', r == '\u2B1C', r == '\u2B50', r == '\u2B55':
+		return true
+	}
+
+	return false
+}
+
+// isTextEmoji reports whether r is a symbol that is only an emoji when
+// followed by a variation selector, e.g. the copyright sign
+func isTextEmoji(r rune) bool {
+	switch {
+	case r == '\u00A9', r == '\u00AE', r == '\u203C', r == '\u2049',
+		r == '\u2122', r == '\u2139', r == '\u24C2', r == '\u3030',
+		r == '\u303D', r == '\u3297', r == '\u3299':
+		return true
+	case r >= '\u2194' && r <= '\u21AA',
+		r >= '\u25AA' && r <= '\u25FE':
+		return true
+	}
+
+	return false
+}
+
+func isSkinTone(r rune) bool {
+	return r >= '\U0001F3FB' && r <= '\U0001F3FF'
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorA && r <= regionalIndicatorZ
+}
+
+// analyzeEmojis counts the emojis in the message content
+func (a *Analysis) analyzeEmojis(sender string, content string) {
+	for _, emoji := range ExtractEmojis(content) {
+		a.Emojis[emoji]++
+		a.participant(sender).Emojis[emoji]++
+	}
+}
@@ -0,0 +1,61 @@
+package message
+
+import (
+	"reflect"
+	"unicode/utf8"
+)
+
+// repairMojibake undoes Facebook's double encoding, where every byte of the
+// UTF-8 text is escaped as its own \u00XX code point. Strings that contain
+// code points above Latin-1, or whose bytes are not valid UTF-8, were not
+// double encoded and are returned unchanged.
+func repairMojibake(s string) string {
+	needsRepair := false
+	for _, r := range s {
+		if r > 0xFF {
+			return s
+		}
+		if r >= 0x80 {
+			needsRepair = true
+		}
+	}
+	if !needsRepair {
+		return s
+	}
+
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		b = append(b, byte(r))
+	}
+	if !utf8.Valid(b) {
+		return s
+	}
+
+	return string(b)
+}
+
+// repairStrings repairs every string field reachable from v, v must be a pointer
+func repairStrings(v interface{}) {
+	repairValue(reflect.ValueOf(v))
+}
+
+func repairValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(repairMojibake(v.String()))
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			repairValue(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			repairValue(v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			repairValue(v.Index(i))
+		}
+	}
+}
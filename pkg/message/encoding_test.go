@@ -0,0 +1,82 @@
+package message
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRepairMojibake(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"escaped emoji", "ð\u009f\u0098\u008d", "😍"},
+		{"escaped emoji in text", "love it ð\u009f\u0098\u0082ð\u009f\u0098\u0082", "love it 😂😂"},
+		{"accented name", "JosÃ© MuÃ±oz", "José Muñoz"},
+		{"central european name", "PaweÅ\u0082 Å»ak", "Paweł Żak"},
+		{"cyrillic", "Ð\u009fÑ\u0080Ð¸Ð²ÐµÑ\u0082", "Привет"},
+		{"ascii", "see you at 7", "see you at 7"},
+		{"empty", "", ""},
+		{"latin-1 that is not utf-8", "café", "café"},
+		{"already decoded emoji", "nice 😍", "nice 😍"},
+		{"already decoded accents and emoji", "José 👍", "José 👍"},
+		{"truncated sequence", "ð\u009f\u0098", "ð\u009f\u0098"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repairMojibake(tt.in); got != tt.want {
+				t.Errorf("repairMojibake(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want Message
+	}{
+		{
+			name: "nested fields",
+			json: `{"sender_name": "JosÃ© MuÃ±oz", "timestamp_ms": 1,
+				"content": "ð\u009f\u0098\u008d",
+				"reactions": [{"reaction": "ð\u009f\u0091\u008d", "actor": "ZoÃ« Smith"}],
+				"share": {"link": "https://example.com", "share_text": "CafÃ©"},
+				"users": [{"name": "RenÃ©e"}]}`,
+			want: Message{
+				SenderName:  "José Muñoz",
+				TimestampMs: 1,
+				Content:     "😍",
+				Reactions:   &[]Reaction{{Reaction: "👍", Actor: "Zoë Smith"}},
+				Share:       &Share{Link: "https://example.com", ShareText: "Café"},
+				Users:       []User{{Name: "Renée"}},
+			},
+		},
+		{
+			name: "unchanged",
+			json: `{"sender_name": "Alice Smith", "timestamp_ms": 2, "content": "café 😍"}`,
+			want: Message{
+				SenderName:  "Alice Smith",
+				TimestampMs: 2,
+				Content:     "café 😍",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Message
+			if err := json.Unmarshal([]byte(tt.json), &m); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			repairStrings(&m)
+			if !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("repairStrings() = %+v, want %+v", m, tt.want)
+			}
+		})
+	}
+}
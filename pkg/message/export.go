@@ -0,0 +1,420 @@
+package message
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// messageFileRegexp matches the numbered parts of a thread, e.g. message_1.json
+// or message_1.html for HTML exports
+var messageFileRegexp = regexp.MustCompile(`^message(?:_(\d+))?\.(?:json|html)$`)
+
+// exportFile is a single thread part found while walking an export
+type exportFile struct {
+	Thread string
+	Part   int
+	Name   string
+	HTML   bool
+	Open   func() (io.ReadCloser, error)
+}
+
+// threadFiles holds every part belonging to a single thread folder
+type threadFiles struct {
+	Thread string
+	Parts  []exportFile
+}
+
+// ParseExport walks every path given, which may be the root of a
+// Messenger export in JSON or HTML, a single thread folder, a single
+// message.json or message_1.html, or a
+// downloaded .zip archive, and returns one merged Blob per thread sorted
+// by thread folder. Large accounts are downloaded as several zips, pass
+// every part and threads split across them are merged.
+func ParseExport(paths ...string) ([]Blob, error) {
+	threads, closeExport, err := collectExport(paths...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeExport()
+
+	blobs := []Blob{}
+	for _, t := range threads {
+		b, err := parseThread(t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse thread %s", t.Thread)
+		}
+		blobs = append(blobs, b)
+	}
+
+	return blobs, nil
+}
+
+// ParseExportThread finds the thread in the export paths, as accepted by
+// ParseExport, and parses only that thread's parts. The thread may be left
+// empty when the export holds a single thread.
+func ParseExportThread(thread string, paths ...string) (Blob, error) {
+	threads, closeExport, err := collectExport(paths...)
+	if err != nil {
+		return Blob{}, err
+	}
+	defer closeExport()
+
+	t, err := findThreadFiles(threads, thread)
+	if err != nil {
+		return Blob{}, err
+	}
+
+	b, err := parseThread(t)
+	if err != nil {
+		return Blob{}, errors.Wrapf(err, "failed to parse thread %s", t.Thread)
+	}
+
+	return b, nil
+}
+
+// collectExport finds and groups the thread parts of every path, the
+// returned func closes any archive opened along the way
+func collectExport(paths ...string) ([]threadFiles, func(), error) {
+	files := []exportFile{}
+	archives := []io.Closer{}
+	closeExport := func() {
+		for _, a := range archives {
+			a.Close()
+		}
+	}
+
+	for _, p := range paths {
+		var found []exportFile
+		var err error
+		if isZip(p) {
+			var archive io.Closer
+			found, archive, err = collectZip(p)
+			if err == nil {
+				archives = append(archives, archive)
+			}
+		} else {
+			found, err = collectDir(p)
+		}
+		if err != nil {
+			closeExport()
+			return nil, nil, errors.Wrapf(err, "failed to read %s", p)
+		}
+		files = append(files, found...)
+	}
+
+	threads := groupThreads(files)
+	if len(threads) == 0 {
+		closeExport()
+		return nil, nil, errors.New("no message files found")
+	}
+
+	return threads, closeExport, nil
+}
+
+// findThreadFiles returns the thread whose folder or title matches name, name
+// may be empty when there is only one thread. Titles are only read when no
+// folder matches.
+func findThreadFiles(threads []threadFiles, name string) (threadFiles, error) {
+	if name == "" {
+		if len(threads) > 1 {
+			names := []string{}
+			for _, t := range threads {
+				names = append(names, t.Thread)
+			}
+			return threadFiles{}, errors.Errorf("export contains %d threads, choose one of:\n%s", len(threads), strings.Join(names, "\n"))
+		}
+		return threads[0], nil
+	}
+
+	for _, t := range threads {
+		if t.Thread == name || t.Thread == path.Base(name) {
+			return t, nil
+		}
+	}
+
+	for _, t := range threads {
+		title, err := threadTitle(t)
+		if err != nil {
+			return threadFiles{}, errors.Wrapf(err, "failed to read the title of %s", t.Thread)
+		}
+		if strings.EqualFold(title, name) {
+			return t, nil
+		}
+	}
+
+	return threadFiles{}, errors.Errorf("no thread named %s", name)
+}
+
+// threadTitle reads the title from the first part of the thread without
+// holding its messages in memory
+func threadTitle(t threadFiles) (string, error) {
+	f := t.Parts[0]
+	r, err := f.Open()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open file")
+	}
+	defer r.Close()
+
+	if f.HTML {
+		b, err := decodeHTMLBlob(r)
+		if err != nil {
+			return "", err
+		}
+		return b.Title, nil
+	}
+
+	return peekTitle(r)
+}
+
+// peekTitle scans the top level keys of a message.json for the title, the
+// values of every other key are skipped token by token
+func peekTitle(r io.Reader) (string, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return "", err
+	}
+
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return "", errors.Wrap(err, "json failed to parse")
+		}
+		key, ok := t.(string)
+		if !ok {
+			return "", errors.Errorf("expected object key, got %v", t)
+		}
+
+		if key == "title" {
+			var title string
+			if err := dec.Decode(&title); err != nil {
+				return "", errors.Wrap(err, "failed to parse title")
+			}
+			return repairMojibake(title), nil
+		}
+		if err := skipValue(dec); err != nil {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// skipValue consumes the next value from dec without decoding it
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return errors.Wrap(err, "json failed to parse")
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// collectDir finds every thread part below root, root itself may be a file
+func collectDir(root string) ([]exportFile, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []exportFile{}
+	err = filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		f, ok := newExportFile(filepath.ToSlash(p))
+		if !ok {
+			return nil
+		}
+		f.Open = func() (io.ReadCloser, error) {
+			return os.Open(p)
+		}
+		files = append(files, f)
+		return nil
+	})
+
+	return files, err
+}
+
+func isZip(p string) bool {
+	info, err := os.Stat(p)
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	return strings.EqualFold(filepath.Ext(p), ".zip")
+}
+
+// collectZip finds every thread part inside the archive without extracting
+// it, the returned closer must be closed once the parts have been read
+func collectZip(p string) ([]exportFile, io.Closer, error) {
+	archive, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := []exportFile{}
+	for _, zf := range archive.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+
+		f, ok := newExportFile(zf.Name)
+		if !ok {
+			continue
+		}
+		f.Name = p + ":" + zf.Name
+		f.Open = zf.Open
+		files = append(files, f)
+	}
+
+	return files, archive, nil
+}
+
+// newExportFile returns the export file for a slash separated path if the
+// path names a thread part
+func newExportFile(p string) (exportFile, bool) {
+	name := path.Base(p)
+	match := messageFileRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return exportFile{}, false
+	}
+
+	part := 0
+	if match[1] != "" {
+		part, _ = strconv.Atoi(match[1])
+	}
+
+	return exportFile{
+		Thread: path.Base(path.Dir(p)),
+		Part:   part,
+		Name:   p,
+		HTML:   path.Ext(name) == ".html",
+	}, true
+}
+
+// groupThreads buckets the files by thread and orders each thread's parts
+func groupThreads(files []exportFile) []threadFiles {
+	byThread := make(map[string]*threadFiles)
+	for _, f := range files {
+		if _, ok := byThread[f.Thread]; !ok {
+			byThread[f.Thread] = &threadFiles{Thread: f.Thread}
+		}
+		byThread[f.Thread].Parts = append(byThread[f.Thread].Parts, f)
+	}
+
+	threads := []threadFiles{}
+	for _, t := range byThread {
+		sort.SliceStable(t.Parts, func(i, j int) bool {
+			return t.Parts[i].Part < t.Parts[j].Part
+		})
+		threads = append(threads, *t)
+	}
+
+	sort.Slice(threads, func(i, j int) bool {
+		return threads[i].Thread < threads[j].Thread
+	})
+
+	return threads
+}
+
+// parseThread decodes every part of the thread and merges them
+func parseThread(t threadFiles) (Blob, error) {
+	blobs := []Blob{}
+	for _, part := range t.Parts {
+		b, err := parseExportFile(part)
+		if err != nil {
+			return Blob{}, errors.Wrapf(err, "failed to parse %s", part.Name)
+		}
+		blobs = append(blobs, b)
+	}
+
+	b := MergeBlobs(blobs...)
+	if b.ThreadPath == "" {
+		b.ThreadPath = t.Thread
+	}
+
+	return b, nil
+}
+
+func parseExportFile(f exportFile) (Blob, error) {
+	r, err := f.Open()
+	if err != nil {
+		return Blob{}, errors.Wrap(err, "failed to open file")
+	}
+	defer r.Close()
+
+	if f.HTML {
+		return decodeHTMLBlob(r)
+	}
+	return decodeBlob(r)
+}
+
+// MergeBlobs merges the parts of a thread into a single Blob with the
+// participants de-duplicated and the messages in chronological order
+func MergeBlobs(blobs ...Blob) Blob {
+	merged := Blob{
+		Participants: []Participant{},
+		Messages:     []Message{},
+	}
+	seen := make(map[string]bool)
+
+	for _, b := range blobs {
+		if merged.Title == "" {
+			merged.Title = b.Title
+		}
+		if merged.ThreadPath == "" {
+			merged.ThreadPath = b.ThreadPath
+		}
+
+		for _, p := range b.Participants {
+			if seen[p.Name] {
+				continue
+			}
+			seen[p.Name] = true
+			merged.Participants = append(merged.Participants, p)
+		}
+
+		// exports list messages newest first, reversing each part before the
+		// stable sort keeps messages that share a timestamp in the order they
+		// were sent whatever order the parts are given in
+		start := len(merged.Messages)
+		merged.Messages = append(merged.Messages, b.Messages...)
+		part := merged.Messages[start:]
+		if len(part) > 1 && part[0].TimestampMs > part[len(part)-1].TimestampMs {
+			for i, j := 0, len(part)-1; i < j; i, j = i+1, j-1 {
+				part[i], part[j] = part[j], part[i]
+			}
+		}
+	}
+
+	msgs := merged.Messages
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return msgs[i].TimestampMs < msgs[j].TimestampMs
+	})
+
+	return merged
+}
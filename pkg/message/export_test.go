@@ -0,0 +1,63 @@
+package message
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMergeBlobs(t *testing.T) {
+	msg := func(ts int64, content string) Message {
+		return Message{SenderName: "Alice Smith", TimestampMs: ts, Content: content}
+	}
+
+	// both parts list messages newest first like the export does, a and b
+	// and e1 and e2 were sent in the same millisecond
+	newer := Blob{
+		Title:        "Trip",
+		ThreadPath:   "inbox/trip_abc123",
+		Participants: []Participant{{Name: "Alice Smith"}, {Name: "Bob Jones"}},
+		Messages:     []Message{msg(6, "f"), msg(5, "e2"), msg(5, "e1"), msg(4, "d")},
+	}
+	older := Blob{
+		Title:        "Trip",
+		ThreadPath:   "inbox/trip_abc123",
+		Participants: []Participant{{Name: "Bob Jones"}, {Name: "Alice Smith"}, {Name: "Carol White"}},
+		Messages:     []Message{msg(3, "c"), msg(2, "b"), msg(2, "a")},
+	}
+	want := []string{"a", "b", "c", "d", "e1", "e2", "f"}
+
+	tests := []struct {
+		name  string
+		blobs []Blob
+	}{
+		{"newest part first", []Blob{newer, older}},
+		{"oldest part first", []Blob{older, newer}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := MergeBlobs(tt.blobs...)
+			if b.Title != "Trip" || b.ThreadPath != "inbox/trip_abc123" {
+				t.Errorf("MergeBlobs() title %q thread path %q, want the parts'", b.Title, b.ThreadPath)
+			}
+
+			names := []string{}
+			for _, p := range b.Participants {
+				names = append(names, p.Name)
+			}
+			sort.Strings(names)
+			if want := []string{"Alice Smith", "Bob Jones", "Carol White"}; !reflect.DeepEqual(names, want) {
+				t.Errorf("MergeBlobs() participants = %v, want %v", names, want)
+			}
+
+			got := []string{}
+			for _, m := range b.Messages {
+				got = append(got, m.Content)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("MergeBlobs() messages = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,391 @@
+package message
+
+import (
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// htmlTimestampLayouts are the timestamp formats used by the HTML export
+// over the years, timestamps are written in the downloader's local time
+var htmlTimestampLayouts = []string{
+	"Jan 2, 2006, 3:04 PM",
+	"Jan 2, 2006, 3:04:05 PM",
+	"Jan 02, 2006 3:04:05pm",
+	"Jan 02, 2006 3:04pm",
+	"Monday, January 2, 2006 at 3:04 PM MST",
+}
+
+var participantsSplitRegexp = regexp.MustCompile(`,\s*|\s+and\s+`)
+
+// htmlNode is an element or text node of a parsed HTML document
+type htmlNode struct {
+	Tag      string
+	Attrs    map[string]string
+	Text     string
+	Children []*htmlNode
+}
+
+var voidElements = stringsToMap([]string{"area", "base", "br", "col", "embed",
+	"hr", "img", "input", "link", "meta", "param", "source", "track", "wbr"})
+
+// ParseHTMLMessages returns the data structure for a message_1.html
+// from an HTML export
+func ParseHTMLMessages(filepath string) (Blob, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return Blob{}, errors.Wrap(err, "failed to read file")
+	}
+	defer f.Close()
+
+	return decodeHTMLBlob(f)
+}
+
+// decodeHTMLBlob extracts the thread from an HTML export page. Messages
+// are found by shape rather than by Facebook's generated class names: a
+// message is an element with exactly three element children, the sender,
+// the body and a timestamp.
+func decodeHTMLBlob(r io.Reader) (Blob, error) {
+	dat, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Blob{}, errors.Wrap(err, "failed to read html")
+	}
+
+	root := parseHTML(string(dat))
+	b := Blob{
+		Participants: []Participant{},
+		Messages:     []Message{},
+	}
+
+	root.walk(func(n *htmlNode) bool {
+		if n.Tag == "title" && b.Title == "" {
+			b.Title = strings.TrimSpace(n.text())
+			return false
+		}
+
+		text := strings.TrimSpace(n.ownText())
+		if strings.HasPrefix(text, "Participants:") && len(b.Participants) == 0 {
+			b.Participants = parseHTMLParticipants(text)
+			return false
+		}
+
+		if m, ok := parseHTMLMessage(n); ok {
+			b.Messages = append(b.Messages, m)
+			return false
+		}
+
+		return true
+	})
+
+	return b, nil
+}
+
+func parseHTMLParticipants(text string) []Participant {
+	participants := []Participant{}
+	text = strings.TrimPrefix(text, "Participants:")
+	for _, name := range participantsSplitRegexp.Split(text, -1) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		participants = append(participants, Participant{Name: name})
+	}
+
+	return participants
+}
+
+func parseHTMLMessage(n *htmlNode) (Message, bool) {
+	children := n.elements()
+	if len(children) != 3 {
+		return Message{}, false
+	}
+
+	ts, ok := parseHTMLTimestamp(strings.TrimSpace(children[2].text()))
+	if !ok {
+		return Message{}, false
+	}
+
+	m := Message{
+		SenderName:  strings.TrimSpace(children[0].text()),
+		TimestampMs: ts.UnixNano() / int64(time.Millisecond),
+		Type:        TypeGeneric,
+	}
+
+	body := children[1]
+	reactions := []Reaction{}
+	texts := []string{}
+	body.walk(func(c *htmlNode) bool {
+		switch c.Tag {
+		case "ul":
+			for _, li := range c.elements() {
+				if r, ok := parseHTMLReaction(li.text()); ok {
+					reactions = append(reactions, r)
+				}
+			}
+			return false
+		case "img", "video", "audio", "a":
+			addHTMLMedia(&m, c)
+		case "":
+			if t := strings.TrimSpace(c.Text); t != "" {
+				texts = append(texts, t)
+			}
+		}
+		return true
+	})
+
+	m.Content = strings.Join(texts, " ")
+	if len(reactions) > 0 {
+		m.Reactions = &reactions
+	}
+
+	return m, true
+}
+
+// addHTMLMedia records the media referenced by the element on the message,
+// the kind of media is taken from the folder the export stores it in
+func addHTMLMedia(m *Message, n *htmlNode) {
+	uri := n.Attrs["src"]
+	if uri == "" {
+		uri = n.Attrs["href"]
+	}
+
+	switch {
+	case uri == "":
+	case strings.Contains(uri, "stickers_used"):
+		m.Sticker = &Sticker{URI: uri}
+	case strings.Contains(uri, "/photos/") && n.Tag != "a":
+		m.Photos = append(m.Photos, Photo{URI: uri})
+	case strings.Contains(uri, "/videos/") && n.Tag != "a":
+		m.Videos = append(m.Videos, Video{URI: uri})
+	case strings.Contains(uri, "/audio/") && n.Tag != "a":
+		m.AudioFiles = append(m.AudioFiles, AudioFile{URI: uri})
+	case strings.Contains(uri, "/gifs/") && n.Tag != "a":
+		m.GIFs = append(m.GIFs, GIF{URI: uri})
+	case strings.Contains(uri, "/files/"):
+		m.Files = append(m.Files, File{URI: uri})
+	case n.Tag == "a" && (strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://")):
+		if m.Share == nil {
+			m.Share = &Share{Link: uri}
+			m.Type = TypeShare
+		}
+	}
+}
+
+// parseHTMLReaction splits a reaction such as "😍Alice Smith" into the
+// reaction and its actor
+func parseHTMLReaction(s string) (Reaction, bool) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, unicode.IsLetter)
+	if i <= 0 {
+		return Reaction{}, false
+	}
+
+	return Reaction{
+		Reaction: strings.TrimSpace(s[:i]),
+		Actor:    strings.TrimSpace(s[i:]),
+	}, true
+}
+
+func parseHTMLTimestamp(s string) (time.Time, bool) {
+	for _, layout := range htmlTimestampLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// walk visits n and its descendants depth first, children are skipped
+// when visit returns false
+func (n *htmlNode) walk(visit func(*htmlNode) bool) {
+	if !visit(n) {
+		return
+	}
+	for _, c := range n.Children {
+		c.walk(visit)
+	}
+}
+
+// elements returns the element children of n
+func (n *htmlNode) elements() []*htmlNode {
+	elements := []*htmlNode{}
+	for _, c := range n.Children {
+		if c.Tag != "" {
+			elements = append(elements, c)
+		}
+	}
+
+	return elements
+}
+
+// text returns all the text below n
+func (n *htmlNode) text() string {
+	var sb strings.Builder
+	n.walk(func(c *htmlNode) bool {
+		sb.WriteString(c.Text)
+		return true
+	})
+
+	return sb.String()
+}
+
+// ownText returns the text of the direct text children of n
+func (n *htmlNode) ownText() string {
+	var sb strings.Builder
+	for _, c := range n.Children {
+		sb.WriteString(c.Text)
+	}
+
+	return sb.String()
+}
+
+// parseHTML builds a lenient document tree, it understands just enough
+// HTML for the well formed pages Facebook generates
+func parseHTML(s string) *htmlNode {
+	root := &htmlNode{Tag: "#document"}
+	stack := []*htmlNode{root}
+	top := func() *htmlNode {
+		return stack[len(stack)-1]
+	}
+
+	for len(s) > 0 {
+		lt := strings.IndexByte(s, '<')
+		if lt != 0 {
+			if lt < 0 {
+				lt = len(s)
+			}
+			top().Children = append(top().Children, &htmlNode{Text: html.UnescapeString(s[:lt])})
+			s = s[lt:]
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			s = skipPast(s, "-->")
+		case strings.HasPrefix(s, "<!"), strings.HasPrefix(s, "<?"):
+			s = skipPast(s, ">")
+		case strings.HasPrefix(s, "</"):
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				return root
+			}
+			tag := strings.ToLower(strings.TrimSpace(s[2:end]))
+			s = s[end+1:]
+
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].Tag == tag {
+					stack = stack[:i]
+					break
+				}
+			}
+		default:
+			n, rest, selfClosing := parseHTMLTag(s)
+			if n == nil {
+				top().Children = append(top().Children, &htmlNode{Text: "<"})
+				s = s[1:]
+				continue
+			}
+			s = rest
+			top().Children = append(top().Children, n)
+
+			if n.Tag == "script" || n.Tag == "style" {
+				s = skipPast(s, "</"+n.Tag+">")
+				continue
+			}
+			if !selfClosing && !voidElements[n.Tag] {
+				stack = append(stack, n)
+			}
+		}
+	}
+
+	return root
+}
+
+// parseHTMLTag parses the start tag at the beginning of s and returns the
+// node, the remaining input and whether the tag closed itself
+func parseHTMLTag(s string) (*htmlNode, string, bool) {
+	i := 1
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	if i == 1 {
+		return nil, s, false
+	}
+
+	n := &htmlNode{
+		Tag:   strings.ToLower(s[1:i]),
+		Attrs: make(map[string]string),
+	}
+
+	for i < len(s) {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			return n, s[i+1:], false
+		}
+		if strings.HasPrefix(s[i:], "/>") {
+			return n, s[i+2:], true
+		}
+		if s[i] == '/' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(s[start:i])
+
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end < 0 {
+					return nil, s, false
+				}
+				value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+		n.Attrs[name] = html.UnescapeString(value)
+	}
+
+	return n, "", false
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// skipPast returns s after the first occurrence of sep, or nothing if
+// sep does not occur
+func skipPast(s string, sep string) string {
+	i := strings.Index(s, sep)
+	if i < 0 {
+		return ""
+	}
+
+	return s[i+len(sep):]
+}
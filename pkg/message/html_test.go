@@ -0,0 +1,107 @@
+package message
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// htmlPage wraps message elements in the page layout of an HTML export
+func htmlPage(messages string) string {
+	return `<!DOCTYPE html>
+<html><head><meta charset="UTF-8" /><title>Weekend Plans</title>
+<style>._3-96{margin:0}</style></head>
+<body><div class="_4t5n" role="main">
+<div class="_3-8y _3-95 _a6-g"><div class="_2lek">Participants: Alice Smith, Bob Jones and Carol White</div></div>
+` + messages + `
+</div></body></html>`
+}
+
+func htmlMillis(year int, month time.Month, day, hour, min int) int64 {
+	return time.Date(year, month, day, hour, min, 0, 0, time.Local).UnixNano() / int64(time.Millisecond)
+}
+
+func TestDecodeHTMLBlob(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages string
+		want     []Message
+	}{
+		{
+			name: "text",
+			messages: `<div class="pam _3-95 _2ph- _a6-g uiBoxWhite noborder">
+<div class="_3-96 _2pio _2lek _2lel">Alice Smith</div>
+<div class="_3-96 _2let"><div><div></div><div>Dinner at 7? &amp; bring snacks</div></div></div>
+<div class="_3-94 _2lem">Mar 4, 2019, 6:05 PM</div></div>`,
+			want: []Message{{
+				SenderName:  "Alice Smith",
+				TimestampMs: htmlMillis(2019, time.March, 4, 18, 5),
+				Content:     "Dinner at 7? & bring snacks",
+				Type:        TypeGeneric,
+			}},
+		},
+		{
+			name: "reactions",
+			messages: `<div class="pam _3-95 _2ph- _a6-g uiBoxWhite noborder">
+<div class="_3-96 _2pio _2lek _2lel">Bob Jones</div>
+<div class="_3-96 _2let"><div><div></div><div>sounds good</div></div>
+<ul class="_tqp"><li>😍Alice Smith</li><li>👍Carol White</li></ul></div>
+<div class="_3-94 _2lem">Mar 4, 2019, 6:07:30 PM</div></div>`,
+			want: []Message{{
+				SenderName:  "Bob Jones",
+				TimestampMs: htmlMillis(2019, time.March, 4, 18, 7) + 30000,
+				Content:     "sounds good",
+				Type:        TypeGeneric,
+				Reactions: &[]Reaction{
+					{Reaction: "😍", Actor: "Alice Smith"},
+					{Reaction: "👍", Actor: "Carol White"},
+				},
+			}},
+		},
+		{
+			name: "photo and link",
+			messages: `<div class="pam _3-95 _2ph- _a6-g uiBoxWhite noborder">
+<div class="_3-96 _2pio _2lek _2lel">Carol White</div>
+<div class="_3-96 _2let"><div><div></div><div>
+<a href="messages/inbox/weekendplans_abc123/photos/123_456.jpg"><img src="messages/inbox/weekendplans_abc123/photos/123_456.jpg" class="_2yuc _3-96" /></a>
+</div></div></div>
+<div class="_3-94 _2lem">Mar 05, 2019 9:15am</div></div>
+<div class="pam _3-95 _2ph- _a6-g uiBoxWhite noborder">
+<div class="_3-96 _2pio _2lek _2lel">Alice Smith</div>
+<div class="_3-96 _2let"><div><div></div><div><a href="https://example.com/menu">https://example.com/menu</a></div></div></div>
+<div class="_3-94 _2lem">Mar 5, 2019, 9:20 AM</div></div>`,
+			want: []Message{{
+				SenderName:  "Carol White",
+				TimestampMs: htmlMillis(2019, time.March, 5, 9, 15),
+				Type:        TypeGeneric,
+				Photos:      []Photo{{URI: "messages/inbox/weekendplans_abc123/photos/123_456.jpg"}},
+			}, {
+				SenderName:  "Alice Smith",
+				TimestampMs: htmlMillis(2019, time.March, 5, 9, 20),
+				Content:     "https://example.com/menu",
+				Type:        TypeShare,
+				Share:       &Share{Link: "https://example.com/menu"},
+			}},
+		},
+	}
+
+	participants := []Participant{{Name: "Alice Smith"}, {Name: "Bob Jones"}, {Name: "Carol White"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := decodeHTMLBlob(strings.NewReader(htmlPage(tt.messages)))
+			if err != nil {
+				t.Fatalf("decodeHTMLBlob() error = %v", err)
+			}
+			if b.Title != "Weekend Plans" {
+				t.Errorf("Title = %q, want %q", b.Title, "Weekend Plans")
+			}
+			if !reflect.DeepEqual(b.Participants, participants) {
+				t.Errorf("Participants = %v, want %v", b.Participants, participants)
+			}
+			if !reflect.DeepEqual(b.Messages, tt.want) {
+				t.Errorf("Messages = %+v, want %+v", b.Messages, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package message
+
+import (
+	"sort"
+)
+
+// InteractionGraph is a directed graph of who replies to, mentions and
+// reacts to whom
+type InteractionGraph struct {
+	Nodes []InteractionNode
+	Edges []InteractionEdge
+}
+
+// InteractionNode is a participant and how many messages they sent
+type InteractionNode struct {
+	Name     string
+	Messages int
+}
+
+// InteractionEdge counts how often From replied to, mentioned and reacted
+// to To, Weight is the sum of the three
+type InteractionEdge struct {
+	From      string
+	To        string
+	Replies   int
+	Mentions  int
+	Reactions int
+	Weight    int
+}
+
+// newInteractionGraph builds the graph from the replies within the response
+// cutoff, the mentions that resolve to a participant and the reactions of
+// the analysis. Interactions with oneself are left out.
+func newInteractionGraph(a Analysis) InteractionGraph {
+	g := InteractionGraph{
+		Nodes: []InteractionNode{},
+		Edges: []InteractionEdge{},
+	}
+
+	edges := make(map[[2]string]*InteractionEdge)
+	edge := func(from, to string) *InteractionEdge {
+		key := [2]string{from, to}
+		if _, ok := edges[key]; !ok {
+			edges[key] = &InteractionEdge{From: from, To: to}
+		}
+		return edges[key]
+	}
+
+	for _, name := range a.Participants.Names() {
+		pa, ok := a.ParticipantAnalyses[name]
+		if !ok {
+			continue
+		}
+		g.Nodes = append(g.Nodes, InteractionNode{Name: name, Messages: pa.MessageCount})
+
+		for to, latencies := range pa.ResponseTimesTo {
+			if to != name {
+				edge(name, to).Replies += len(latencies)
+			}
+		}
+		for to, count := range pa.MentionsOf {
+			if to != name {
+				edge(name, to).Mentions += count
+			}
+		}
+		for to, count := range a.ReactionMatrix[name] {
+			if to != name {
+				edge(name, to).Reactions += count
+			}
+		}
+	}
+
+	for _, e := range edges {
+		e.Weight = e.Replies + e.Mentions + e.Reactions
+		g.Edges = append(g.Edges, *e)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Weight != g.Edges[j].Weight {
+			return g.Edges[i].Weight > g.Edges[j].Weight
+		}
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+
+	return g
+}
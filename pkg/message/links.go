@@ -0,0 +1,134 @@
+package message
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var linkRegexp = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// SharedLink is a link and when it was first shared
+type SharedLink struct {
+	URL           string
+	Domain        string
+	Count         int
+	FirstShared   time.Time
+	FirstSharedBy string
+}
+
+// Links returns the links in the message's content and share, a link
+// shared both ways is returned once
+func (m Message) Links() []string {
+	links := []string{}
+	seen := make(map[string]bool)
+	add := func(link string) {
+		link = strings.TrimRight(link, ".,!?;:)]}'\"")
+		if key := linkKey(link); key != "" && !seen[key] {
+			seen[key] = true
+			links = append(links, link)
+		}
+	}
+
+	for _, link := range linkRegexp.FindAllString(m.Content, -1) {
+		add(link)
+	}
+	if m.Share != nil && m.Share.Link != "" {
+		add(m.Share.Link)
+	}
+
+	return links
+}
+
+// removeLinks blanks out the links in s so they aren't split into words
+func removeLinks(s string) string {
+	return linkRegexp.ReplaceAllString(s, " ")
+}
+
+// LinkDomain returns the host of the link without a leading www.
+func LinkDomain(link string) string {
+	u, err := parseLink(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// linkKey identifies a link regardless of its scheme, a leading www. or
+// the case of its domain
+func linkKey(link string) string {
+	u, err := parseLink(link)
+	if err != nil {
+		return ""
+	}
+	domain := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if domain == "" {
+		return ""
+	}
+
+	key := domain + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+
+	return key
+}
+
+func parseLink(link string) (*url.URL, error) {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+
+	return url.Parse(link)
+}
+
+// analyzeLinks counts the message's links by domain and keeps when each
+// link was first shared, messages may arrive in any order
+func (a *Analysis) analyzeLinks(m Message) {
+	pa := a.participant(m.SenderName)
+	sent := MessageTime(m, a.Options.location())
+	for _, link := range m.Links() {
+		domain := LinkDomain(link)
+		if domain == "" {
+			continue
+		}
+		a.Domains[domain]++
+		pa.Domains[domain]++
+		pa.LinkCount++
+
+		key := linkKey(link)
+		shared, ok := a.Links[key]
+		if !ok {
+			shared = &SharedLink{URL: link, Domain: domain, FirstShared: sent}
+			a.Links[key] = shared
+		}
+		shared.Count++
+		if !sent.After(shared.FirstShared) {
+			shared.FirstShared = sent
+			shared.FirstSharedBy = a.Participants.Canonical(m.SenderName)
+		}
+	}
+}
+
+// sortLinks orders the links by how often they were shared, then by when
+// they were first shared
+func sortLinks(links map[string]*SharedLink) []SharedLink {
+	sorted := []SharedLink{}
+	for _, link := range links {
+		sorted = append(sorted, *link)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		if !sorted[i].FirstShared.Equal(sorted[j].FirstShared) {
+			return sorted[i].FirstShared.Before(sorted[j].FirstShared)
+		}
+		return sorted[i].URL < sorted[j].URL
+	})
+
+	return sorted
+}
@@ -0,0 +1,157 @@
+package message
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MediaKinds lists the media kinds in the order charts show them
+var MediaKinds = []string{MediaPhotos, MediaVideos, MediaAudio, MediaGIFs, MediaFiles, MediaShares}
+
+// analyzeMedia counts the message's media by kind and over time, and adds
+// up how long its voice messages are when their files can be read
+func (a *Analysis) analyzeMedia(m Message, sent time.Time) {
+	pa := a.participant(m.SenderName)
+	for kind, count := range m.MediaCounts() {
+		a.Media[kind] += count
+		pa.Media[kind] += count
+
+		if _, ok := a.MediaActivity[kind]; !ok {
+			a.MediaActivity[kind] = newActivity()
+		}
+		if _, ok := pa.MediaActivity[kind]; !ok {
+			pa.MediaActivity[kind] = newActivity()
+		}
+		for i := 0; i < count; i++ {
+			a.MediaActivity[kind].add(sent)
+			pa.MediaActivity[kind].add(sent)
+		}
+	}
+
+	if a.Options.MediaRoot == "" {
+		return
+	}
+	for _, audio := range m.AudioFiles {
+		duration, err := audioDuration(a.Options.MediaRoot, audio.URI)
+		if err != nil {
+			continue
+		}
+		a.AudioDuration += duration
+		a.AudioMeasured++
+		pa.AudioDuration += duration
+		pa.AudioMeasured++
+	}
+}
+
+func sortMediaActivity(activities map[string]*Activity) map[string]SortedActivity {
+	sorted := make(map[string]SortedActivity)
+	for kind, activity := range activities {
+		sorted[kind] = sortActivity(activity)
+	}
+
+	return sorted
+}
+
+// audioDuration returns the length of the audio file. Media URIs are
+// relative to the root of the export, which may be the media root or any
+// folder above it.
+func audioDuration(root string, uri string) (time.Duration, error) {
+	path := ""
+	for dir := root; ; dir = filepath.Dir(dir) {
+		candidate := filepath.Join(dir, filepath.FromSlash(uri))
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			return 0, errors.Errorf("%s not found", uri)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open audio")
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open audio")
+	}
+
+	return mp4Duration(f, 0, info.Size())
+}
+
+// mp4Duration reads the duration from the movie header box of the MP4
+// boxes between start and end, voice messages are exported as MP4 audio
+func mp4Duration(r io.ReadSeeker, start int64, end int64) (time.Duration, error) {
+	header := make([]byte, 16)
+	for offset := start; offset+8 <= end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return 0, errors.Wrap(err, "failed to read box")
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return 0, errors.Wrap(err, "failed to read box")
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		kind := string(header[4:8])
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return 0, errors.Wrap(err, "failed to read box")
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if size < headerSize {
+			return 0, errors.Errorf("invalid %s box size %d", kind, size)
+		}
+
+		switch kind {
+		case "moov":
+			return mp4Duration(r, offset+headerSize, offset+size)
+		case "mvhd":
+			return mvhdDuration(r)
+		}
+		offset += size
+	}
+
+	return 0, errors.New("no movie header")
+}
+
+// mvhdDuration reads the duration from the body of a movie header box
+func mvhdDuration(r io.Reader) (time.Duration, error) {
+	body := make([]byte, 32)
+	if _, err := io.ReadFull(r, body[:4]); err != nil {
+		return 0, errors.Wrap(err, "failed to read movie header")
+	}
+
+	var timescale, duration uint64
+	if body[0] == 1 {
+		if _, err := io.ReadFull(r, body[:28]); err != nil {
+			return 0, errors.Wrap(err, "failed to read movie header")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(body[16:20]))
+		duration = binary.BigEndian.Uint64(body[20:28])
+	} else {
+		if _, err := io.ReadFull(r, body[:16]); err != nil {
+			return 0, errors.Wrap(err, "failed to read movie header")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(body[8:12]))
+		duration = uint64(binary.BigEndian.Uint32(body[12:16]))
+	}
+	if timescale == 0 {
+		return 0, errors.New("movie header has no timescale")
+	}
+
+	return time.Duration(duration) * time.Second / time.Duration(timescale), nil
+}
@@ -0,0 +1,104 @@
+package message
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// mp4Box encodes a box with a 32 bit size
+func mp4Box(kind string, body ...[]byte) []byte {
+	payload := bytes.Join(body, nil)
+	box := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(box[:4], uint32(8+len(payload)))
+	copy(box[4:8], kind)
+
+	return append(box, payload...)
+}
+
+// mp4LargeBox encodes a box with a 64 bit size
+func mp4LargeBox(kind string, body []byte) []byte {
+	box := make([]byte, 16, 16+len(body))
+	binary.BigEndian.PutUint32(box[:4], 1)
+	copy(box[4:8], kind)
+	binary.BigEndian.PutUint64(box[8:16], uint64(16+len(body)))
+
+	return append(box, body...)
+}
+
+// mvhdV0 encodes a version 0 movie header with 32 bit times
+func mvhdV0(timescale uint32, duration uint32) []byte {
+	body := make([]byte, 100)
+	binary.BigEndian.PutUint32(body[12:16], timescale)
+	binary.BigEndian.PutUint32(body[16:20], duration)
+
+	return mp4Box("mvhd", body)
+}
+
+// mvhdV1 encodes a version 1 movie header with 64 bit times
+func mvhdV1(timescale uint32, duration uint64) []byte {
+	body := make([]byte, 112)
+	body[0] = 1
+	binary.BigEndian.PutUint32(body[20:24], timescale)
+	binary.BigEndian.PutUint64(body[24:32], duration)
+
+	return mp4Box("mvhd", body)
+}
+
+func TestMP4Duration(t *testing.T) {
+	ftyp := mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00M4A isom"))
+	tests := []struct {
+		name    string
+		file    []byte
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name: "version 0",
+			file: bytes.Join([][]byte{ftyp, mp4Box("moov", mvhdV0(1000, 12500))}, nil),
+			want: 12500 * time.Millisecond,
+		},
+		{
+			name: "version 1",
+			file: bytes.Join([][]byte{ftyp, mp4Box("moov", mvhdV1(44100, 44100*90+22050))}, nil),
+			want: 90500 * time.Millisecond,
+		},
+		{
+			name: "boxes before the header",
+			file: bytes.Join([][]byte{
+				ftyp,
+				mp4LargeBox("mdat", make([]byte, 64)),
+				mp4Box("moov", mp4Box("free", make([]byte, 12)), mvhdV0(600, 1800)),
+			}, nil),
+			want: 3 * time.Second,
+		},
+		{
+			name:    "no movie box",
+			file:    bytes.Join([][]byte{ftyp, mp4Box("mdat", make([]byte, 32))}, nil),
+			wantErr: true,
+		},
+		{
+			name:    "no timescale",
+			file:    bytes.Join([][]byte{ftyp, mp4Box("moov", mvhdV0(0, 12500))}, nil),
+			wantErr: true,
+		},
+		{
+			name:    "truncated header",
+			file:    bytes.Join([][]byte{ftyp, mp4Box("moov", mvhdV1(1000, 12500)[:24])}, nil),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mp4Duration(bytes.NewReader(tt.file), 0, int64(len(tt.file)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mp4Duration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("mp4Duration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
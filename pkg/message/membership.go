@@ -0,0 +1,171 @@
+package message
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Membership event kinds
+const (
+	MembershipJoined  = "joined"
+	MembershipLeft    = "left"
+	MembershipRenamed = "renamed"
+	MembershipPhoto   = "photo"
+)
+
+var (
+	renameRegexp = regexp.MustCompile(`^(.+) (?:named the group|changed the group name to) (.+?)\.?$`)
+	photoRegexp  = regexp.MustCompile(`^(.+) changed the group photo\.?$`)
+)
+
+// MembershipEvent is a change to who is in a group chat or to the chat
+// itself. Name is the member who joined or left, Title the new name of a
+// renamed chat.
+type MembershipEvent struct {
+	Time  time.Time
+	Kind  string
+	Name  string
+	By    string
+	Title string
+}
+
+// Tenure is a stretch of time someone was in the chat
+type Tenure struct {
+	Joined time.Time
+	Left   time.Time
+}
+
+// SortedMembership contains the membership events in chronological order
+// and the tenures of every participant
+type SortedMembership struct {
+	Events  []MembershipEvent
+	Tenures map[string][]Tenure
+}
+
+// analyzeMembership records the joins, leaves, renames and photo changes
+// the message records. Members added or removed are listed in users, an
+// empty list means the sender joined or left themselves.
+func (a *Analysis) analyzeMembership(m Message, sent time.Time) {
+	if a.firstMs == 0 || m.TimestampMs < a.firstMs {
+		a.firstMs = m.TimestampMs
+	}
+	if m.TimestampMs > a.lastMs {
+		a.lastMs = m.TimestampMs
+	}
+
+	by := a.Participants.Canonical(m.SenderName)
+	add := func(kind string) {
+		names := []string{by}
+		if len(m.Users) > 0 {
+			names = []string{}
+			for _, u := range m.Users {
+				a.participant(u.Name)
+				names = append(names, a.Participants.Canonical(u.Name))
+			}
+		}
+		for _, name := range names {
+			a.Membership = append(a.Membership, MembershipEvent{Time: sent, Kind: kind, Name: name, By: by})
+		}
+	}
+
+	switch m.Type {
+	case TypeSubscribe:
+		add(MembershipJoined)
+		return
+	case TypeUnsubscribe:
+		add(MembershipLeft)
+		return
+	}
+
+	// every notice mentions the group, checking first keeps the regexps off
+	// ordinary messages
+	if !strings.Contains(m.Content, "the group") {
+		return
+	}
+	if match := matchGroupNotice(renameRegexp, m); match != nil {
+		a.Membership = append(a.Membership, MembershipEvent{Time: sent, Kind: MembershipRenamed, By: by, Title: match[2]})
+	} else if match := matchGroupNotice(photoRegexp, m); match != nil {
+		a.Membership = append(a.Membership, MembershipEvent{Time: sent, Kind: MembershipPhoto, By: by})
+	}
+}
+
+// matchGroupNotice returns the submatches of re when m is a notice the
+// export wrote for its sender, e.g. "Alice Smith named the group Trip", and
+// nil for ordinary messages that happen to read like one. Notices carry
+// nothing but text and name the sender, or "You" in the downloader's export.
+func matchGroupNotice(re *regexp.Regexp, m Message) []string {
+	if m.HasAttachments() || m.Share != nil || m.Sticker != nil {
+		return nil
+	}
+
+	match := re.FindStringSubmatch(m.Content)
+	if match == nil || (match[1] != m.SenderName && match[1] != "You") {
+		return nil
+	}
+
+	return match
+}
+
+// sortMembership orders the events and works out every participant's
+// tenures. Someone whose first event is leaving was in the chat from its
+// first message, someone who never left stays until its last message.
+func sortMembership(a Analysis) SortedMembership {
+	events := append([]MembershipEvent{}, a.Membership...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	loc := a.Options.location()
+	first := time.Unix(0, a.firstMs*int64(time.Millisecond)).In(loc)
+	last := time.Unix(0, a.lastMs*int64(time.Millisecond)).In(loc)
+
+	tenures := make(map[string][]Tenure)
+	joined := make(map[string]time.Time)
+	seen := make(map[string]bool)
+	for _, e := range events {
+		switch e.Kind {
+		case MembershipJoined:
+			if _, ok := joined[e.Name]; !ok {
+				joined[e.Name] = e.Time
+			}
+		case MembershipLeft:
+			start, ok := joined[e.Name]
+			if !ok {
+				if seen[e.Name] {
+					continue
+				}
+				start = first
+			}
+			tenures[e.Name] = append(tenures[e.Name], Tenure{Joined: start, Left: e.Time})
+			delete(joined, e.Name)
+		default:
+			continue
+		}
+		seen[e.Name] = true
+	}
+
+	for _, name := range a.Participants.Names() {
+		if start, ok := joined[name]; ok {
+			tenures[name] = append(tenures[name], Tenure{Joined: start, Left: last})
+		} else if !seen[name] {
+			tenures[name] = []Tenure{{Joined: first, Left: last}}
+		}
+	}
+
+	return SortedMembership{
+		Events:  events,
+		Tenures: tenures,
+	}
+}
+
+// DaysInChat returns how many days the tenures add up to
+func DaysInChat(tenures []Tenure) float64 {
+	total := time.Duration(0)
+	for _, t := range tenures {
+		total += t.Left.Sub(t.Joined)
+	}
+
+	return total.Hours() / 24
+}
@@ -0,0 +1,103 @@
+package message
+
+import (
+	"strings"
+)
+
+// mentionTarget is one way of writing a participant's name in a mention,
+// split into words the way message content is
+type mentionTarget struct {
+	Name  string
+	Words []string
+}
+
+// analyzeMentions resolves the message's @mentions to participants. A
+// mention may continue over the following words, as "@Alice Smith" does,
+// the longest name that matches wins. Mentions matching no one are counted
+// as unresolved.
+func (a *Analysis) analyzeMentions(sender string, words []string) {
+	pa := a.participant(sender)
+	for i, word := range words {
+		if len(word) <= 1 || word[0] != '@' {
+			continue
+		}
+
+		name, ok := a.resolveMention(words[i:])
+		if !ok {
+			a.UnresolvedMentions[word]++
+			pa.UnresolvedMentions[word]++
+			continue
+		}
+		pa.MentionsOf[name]++
+	}
+}
+
+// resolveMention returns the participant named by the mention at the start
+// of words
+func (a *Analysis) resolveMention(words []string) (string, bool) {
+	first := strings.TrimPrefix(words[0], "@")
+	best := mentionTarget{}
+	for _, target := range a.mentionTargets() {
+		if len(target.Words) > len(words) || len(target.Words) <= len(best.Words) || target.Words[0] != first {
+			continue
+		}
+
+		matches := true
+		for j := 1; j < len(target.Words); j++ {
+			if words[j] != target.Words[j] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			best = target
+		}
+	}
+
+	return best.Name, best.Name != ""
+}
+
+// mentionTargets lists every way participants can be mentioned: full names
+// with or without spaces, first names no one else shares, aliases and the
+// configured nicknames. The list is rebuilt when new participants appear.
+func (a *Analysis) mentionTargets() []mentionTarget {
+	names := a.Participants.Names()
+	if a.mentionTargetsFor == len(names) && a.mentionTargetCache != nil {
+		return a.mentionTargetCache
+	}
+
+	tokenizer := a.Options.tokenizer()
+	targets := []mentionTarget{}
+	add := func(written string, name string) {
+		// mentions are matched without their @, nicknames may be given with one
+		words := tokenizer.Tokenize(strings.TrimPrefix(strings.TrimSpace(written), "@"))
+		if len(words) == 0 {
+			return
+		}
+		targets = append(targets, mentionTarget{Name: name, Words: words})
+		if len(words) > 1 {
+			targets = append(targets, mentionTarget{Name: name, Words: []string{strings.Join(words, "")}})
+		}
+	}
+
+	firstNames := make(map[string]int)
+	for _, name := range names {
+		firstNames[nameToFirstName(name)]++
+	}
+	for _, name := range names {
+		add(name, name)
+		if firstNames[nameToFirstName(name)] == 1 {
+			add(nameToFirstName(name), name)
+		}
+	}
+	for alias, name := range a.Participants.Aliases() {
+		add(alias, a.Participants.Canonical(name))
+	}
+	for nickname, name := range a.Options.Nicknames {
+		add(nickname, a.Participants.Canonical(name))
+	}
+
+	a.mentionTargetsFor = len(names)
+	a.mentionTargetCache = targets
+	return targets
+}
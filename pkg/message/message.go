@@ -3,39 +3,16 @@ package message
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"regexp"
+	"io"
+	"os"
+	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
-var ignoreWords = []string{"a", "about", "above", "after", "again", "against",
-	"all", "am", "an", "and", "any", "are", "aren't", "as", "at",
-	"be", "because", "been", "before", "being", "below", "between",
-	"both", "but", "by", "can't", "cannot", "could", "couldn't",
-	"did", "didn't", "do", "does", "doesn't", "doing", "don't",
-	"down", "during", "each", "few", "for", "from", "further", "had",
-	"hadn't", "has", "hasn't", "have", "haven't", "having", "he",
-	"he'd", "he'll", "he's", "her", "here", "here's", "hers", "herself",
-	"him", "himself", "his", "how", "how's", "i", "i'd", "i'll", "i'm",
-	"i've", "if", "in", "into", "is", "isn't", "it", "it's", "its",
-	"itself", "let's", "me", "more", "most", "mustn't", "my", "myself",
-	"no", "nor", "not", "of", "off", "on", "once", "only", "or", "other",
-	"ought", "our", "ours  ourselves", "out", "over", "own", "same",
-	"shan't", "she", "she'd", "she'll", "she's", "should", "shouldn't",
-	"so", "some", "such", "than", "that", "that's", "the", "their",
-	"theirs", "them", "themselves", "then", "there", "there's", "these",
-	"they", "they'd", "they'll", "they're", "they've", "this", "those",
-	"through", "to", "too", "under", "until", "up", "very", "was", "wasn't",
-	"we", "we'd", "we'll", "we're", "we've", "were", "weren't", "what",
-	"what's", "when", "when's", "where", "where's", "which", "while",
-	"who", "who's", "whom", "why", "why's", "with", "won't", "would",
-	"wouldn't", "you", "you'd", "you'll", "you're", "you've", "your",
-	"yours", "yourself", "yourselves", "http", "https", "www", "com",
-	":", "http:", "https:", "im"}
-
 func stringsToMap(strings []string) map[string]bool {
 	m := make(map[string]bool)
 
@@ -46,12 +23,14 @@ func stringsToMap(strings []string) map[string]bool {
 	return m
 }
 
-var ignoreWordMap = stringsToMap(ignoreWords)
-
 // Blob is the struct to represent the entire message.json file
 type Blob struct {
-	Participants []Participant `json:"participants"`
-	Messages     []Message     `json:"messages"`
+	Participants       []Participant `json:"participants"`
+	Messages           []Message     `json:"messages"`
+	Title              string        `json:"title"`
+	ThreadPath         string        `json:"thread_path"`
+	ThreadType         string        `json:"thread_type"`
+	IsStillParticipant bool          `json:"is_still_participant"`
 }
 
 // Participant is the struct to represent each participant
@@ -60,21 +39,175 @@ type Participant struct {
 }
 
 // Message is the struct to represent each message
-// Ignoring sharing photo and attachments
 type Message struct {
-	SenderName  string      `json:"sender_name"`
-	TimestampMs int64       `json:"timestamp_ms"`
-	Content     string      `json:"content"`
-	Sticker     *Sticker    `json:"sticker"`
-	Reactions   *[]Reaction `json:"reactions"`
-	Type        string      `json:"type"`
+	SenderName   string      `json:"sender_name"`
+	TimestampMs  int64       `json:"timestamp_ms"`
+	Content      string      `json:"content"`
+	Sticker      *Sticker    `json:"sticker"`
+	Reactions    *[]Reaction `json:"reactions"`
+	Type         string      `json:"type"`
+	Photos       []Photo     `json:"photos"`
+	Videos       []Video     `json:"videos"`
+	AudioFiles   []AudioFile `json:"audio_files"`
+	GIFs         []GIF       `json:"gifs"`
+	Files        []File      `json:"files"`
+	Share        *Share      `json:"share"`
+	CallDuration int64       `json:"call_duration"`
+	Missed       bool        `json:"missed"`
+	Users        []User      `json:"users"`
+	IsUnsent     bool        `json:"is_unsent"`
+	IP           string      `json:"ip"`
 }
 
+// Message types used by the export, messages without a special type are Generic
+const (
+	TypeGeneric     = "Generic"
+	TypeShare       = "Share"
+	TypeCall        = "Call"
+	TypeSubscribe   = "Subscribe"
+	TypeUnsubscribe = "Unsubscribe"
+)
+
 // Sticker is the struct to represent the message sticker
 type Sticker struct {
 	URI string `json:"uri"`
 }
 
+// Photo is the struct to represent a photo sent in a message
+type Photo struct {
+	URI               string `json:"uri"`
+	CreationTimestamp int64  `json:"creation_timestamp"`
+}
+
+// Video is the struct to represent a video sent in a message
+type Video struct {
+	URI               string     `json:"uri"`
+	CreationTimestamp int64      `json:"creation_timestamp"`
+	Thumbnail         *Thumbnail `json:"thumbnail"`
+}
+
+// Thumbnail is the struct to represent the preview image of a video
+type Thumbnail struct {
+	URI string `json:"uri"`
+}
+
+// AudioFile is the struct to represent a voice message or audio clip
+type AudioFile struct {
+	URI               string `json:"uri"`
+	CreationTimestamp int64  `json:"creation_timestamp"`
+}
+
+// GIF is the struct to represent a gif sent in a message
+type GIF struct {
+	URI string `json:"uri"`
+}
+
+// File is the struct to represent a file attachment
+type File struct {
+	URI               string `json:"uri"`
+	CreationTimestamp int64  `json:"creation_timestamp"`
+}
+
+// Share is the struct to represent a shared link
+type Share struct {
+	Link      string `json:"link"`
+	ShareText string `json:"share_text"`
+}
+
+// User is the struct to represent a member added to or removed from a group
+type User struct {
+	Name string `json:"name"`
+}
+
+// Media kinds counted in Analysis.Media
+const (
+	MediaPhotos = "photos"
+	MediaVideos = "videos"
+	MediaAudio  = "audio"
+	MediaGIFs   = "gifs"
+	MediaFiles  = "files"
+	MediaShares = "shares"
+)
+
+// Events counted in Analysis.Events
+const (
+	EventCalls          = "calls"
+	EventMissedCalls    = "missed calls"
+	EventMembersAdded   = "members added"
+	EventMembersRemoved = "members removed"
+	EventUnsent         = "unsent"
+)
+
+// MediaCounts returns how many of each kind of media the message carries
+func (m Message) MediaCounts() map[string]int {
+	counts := make(map[string]int)
+	if len(m.Photos) > 0 {
+		counts[MediaPhotos] = len(m.Photos)
+	}
+	if len(m.Videos) > 0 {
+		counts[MediaVideos] = len(m.Videos)
+	}
+	if len(m.AudioFiles) > 0 {
+		counts[MediaAudio] = len(m.AudioFiles)
+	}
+	if len(m.GIFs) > 0 {
+		counts[MediaGIFs] = len(m.GIFs)
+	}
+	if len(m.Files) > 0 {
+		counts[MediaFiles] = len(m.Files)
+	}
+	if m.Share != nil {
+		counts[MediaShares] = 1
+	}
+
+	return counts
+}
+
+// HasAttachments reports whether the message carries photos, videos,
+// audio, gifs or files
+func (m Message) HasAttachments() bool {
+	return len(m.Photos)+len(m.Videos)+len(m.AudioFiles)+len(m.GIFs)+len(m.Files) > 0
+}
+
+// IsMissedCall reports whether the message records a call nobody answered,
+// older exports leave missed unset and record those calls with no duration
+func (m Message) IsMissedCall() bool {
+	return m.Type == TypeCall && (m.Missed || m.CallDuration == 0)
+}
+
+// Events returns the calls, membership changes and unsends the message records
+func (m Message) Events() []string {
+	events := []string{}
+	switch m.Type {
+	case TypeCall:
+		if m.IsMissedCall() {
+			events = append(events, EventMissedCalls)
+		} else {
+			events = append(events, EventCalls)
+		}
+	case TypeSubscribe:
+		events = append(events, EventMembersAdded)
+	case TypeUnsubscribe:
+		events = append(events, EventMembersRemoved)
+	}
+	if m.IsUnsent {
+		events = append(events, EventUnsent)
+	}
+
+	return events
+}
+
+// stickerURIToID returns the sticker id from a uri such as
+// messages/stickers_used/39178562_1505197616293642_5411344281094848512_n_369239263222822.png
+func stickerURIToID(uri string) string {
+	id := path.Base(uri)
+	if i := strings.LastIndex(id, "_n_"); i >= 0 {
+		id = id[i+len("_n_"):]
+	}
+
+	return strings.Split(id, ".")[0]
+}
+
 // Reaction is the struct to represent the message reaction
 type Reaction struct {
 	Reaction string `json:"reaction"`
@@ -83,72 +216,253 @@ type Reaction struct {
 
 // ParseMessages returns the data structure for the message.json
 func ParseMessages(filepath string) (Blob, error) {
-	dat, err := ioutil.ReadFile(filepath)
+	f, err := os.Open(filepath)
 	if err != nil {
 		return Blob{}, errors.Wrap(err, "failed to read file")
 	}
+	defer f.Close()
 
+	return decodeBlob(f)
+}
+
+// decodeBlob decodes a single message.json from the reader and repairs
+// the text encoding of every string in it
+func decodeBlob(r io.Reader) (Blob, error) {
 	var b Blob
-	err = json.Unmarshal(dat, &b)
+	err := json.NewDecoder(r).Decode(&b)
 	if err != nil {
 		return Blob{}, errors.Wrap(err, "json failed to parse")
 	}
+	repairStrings(&b)
 
 	return b, nil
 }
 
 // Analysis contains the aggregate analysis and a map
-// for participant to participant anaylsis
+// for participant to participant anaylsis, participants are keyed
+// by their canonical full name
 type Analysis struct {
+	Participants        *Registry
+	Options             Options
 	ParticipantAnalyses map[string]*ParticipantAnalysis
 	Stickers            map[string]int
 	Words               map[string]int
 	Reactions           map[string]int
 	Mentions            map[string]int
+	Media               map[string]int
+	Events              map[string]int
+	Activity            *Activity
+	ResponseTimes       []int64
+	Sessions            *Sessions
+	Phrases             map[int]map[string]int
+	Emojis              map[string]int
+	ReactionMatrix      map[string]map[string]int
+	MostReacted         []ReactedMessage
+	UnresolvedMentions  map[string]int
+	Domains             map[string]int
+	Links               map[string]*SharedLink
+	MediaActivity       map[string]*Activity
+	AudioDuration       time.Duration
+	AudioMeasured       int
+	Calls               *Calls
+	Membership          []MembershipEvent
 	MessageCount        int
+
+	previous           *sentMessage
+	session            *session
+	stopwords          map[string]bool
+	mentionTargetCache []mentionTarget
+	mentionTargetsFor  int
+	firstMs            int64
+	lastMs             int64
 }
 
 // ParticipantAnalysis contains the participant analysis for
-// sticker, word, reactions
+// sticker, word, reactions, media and events
 type ParticipantAnalysis struct {
-	Stickers     map[string]int
-	Words        map[string]int
-	Reactions    map[string]int
-	Mentions     map[string]int
-	MessageCount int
+	Stickers        map[string]int
+	Words           map[string]int
+	Reactions       map[string]int
+	Mentions        map[string]int
+	Media           map[string]int
+	Events          map[string]int
+	Activity        *Activity
+	ResponseTimes   []int64
+	ResponseTimesTo map[string][]int64
+	Phrases         map[int]map[string]int
+	Emojis          map[string]int
+	MessageCount    int
+
+	ReactionsReceived map[string]int
+	ReactionsFrom     map[string]int
+	MostReacted       []ReactedMessage
+
+	MentionsOf         map[string]int
+	UnresolvedMentions map[string]int
+
+	Domains   map[string]int
+	LinkCount int
+
+	MediaActivity map[string]*Activity
+	AudioDuration time.Duration
+	AudioMeasured int
+
+	Calls *Calls
+
+	TextMessages   int
+	WordCount      int
+	CharacterCount int
+	Vocabulary     map[string]bool
+
+	ConversationsStarted int
+	ConversationsEnded   int
 }
 
 func newParticipantAnalysis() *ParticipantAnalysis {
 	return &ParticipantAnalysis{
-		Stickers:     make(map[string]int),
-		Words:        make(map[string]int),
-		Reactions:    make(map[string]int),
-		Mentions:     make(map[string]int),
-		MessageCount: 0,
+		Stickers:        make(map[string]int),
+		Words:           make(map[string]int),
+		Reactions:       make(map[string]int),
+		Mentions:        make(map[string]int),
+		Media:           make(map[string]int),
+		Events:          make(map[string]int),
+		Activity:        newActivity(),
+		ResponseTimes:   []int64{},
+		ResponseTimesTo: make(map[string][]int64),
+		Phrases:         make(map[int]map[string]int),
+		Emojis:          make(map[string]int),
+		MessageCount:    0,
+
+		ReactionsReceived: make(map[string]int),
+		ReactionsFrom:     make(map[string]int),
+		MostReacted:       []ReactedMessage{},
+
+		MentionsOf:         make(map[string]int),
+		UnresolvedMentions: make(map[string]int),
+
+		Domains:   make(map[string]int),
+		LinkCount: 0,
+
+		MediaActivity: make(map[string]*Activity),
+
+		Calls: newCalls(),
+
+		Vocabulary: make(map[string]bool),
 	}
 }
 
-func newAnalysis() Analysis {
+func newAnalysis(opts Options) Analysis {
 	return Analysis{
+		Participants:        NewRegistry(opts.Aliases),
+		Options:             opts,
 		ParticipantAnalyses: make(map[string]*ParticipantAnalysis),
 		Stickers:            make(map[string]int),
 		Words:               make(map[string]int),
 		Reactions:           make(map[string]int),
 		Mentions:            make(map[string]int),
+		Media:               make(map[string]int),
+		Events:              make(map[string]int),
+		Activity:            newActivity(),
+		ResponseTimes:       []int64{},
+		Sessions:            newSessions(),
+		Phrases:             make(map[int]map[string]int),
+		Emojis:              make(map[string]int),
+		ReactionMatrix:      make(map[string]map[string]int),
+		MostReacted:         []ReactedMessage{},
+		UnresolvedMentions:  make(map[string]int),
+		Domains:             make(map[string]int),
+		Links:               make(map[string]*SharedLink),
+		MediaActivity:       make(map[string]*Activity),
+		Calls:               newCalls(),
+		Membership:          []MembershipEvent{},
 		MessageCount:        0,
+		stopwords:           stopwordSet(opts.Stopwords, opts.tokenizer()),
+	}
+}
+
+// Options configures how messages are analyzed
+type Options struct {
+	// Aliases merges a name, e.g. an account's old name, into another
+	Aliases map[string]string
+	// Display chooses how participants are named in charts
+	Display DisplayStrategy
+	// Location is the time zone activity is bucketed in
+	Location *time.Location
+	// ResponseCutoff is the longest gap still counted as a reply, zero
+	// counts every gap
+	ResponseCutoff time.Duration
+	// SessionGap is the silence that ends a conversation, zero treats the
+	// whole thread as one conversation
+	SessionGap time.Duration
+	// NGramSizes are the phrase lengths counted alongside single words
+	NGramSizes []int
+	// Tokenizer splits message content into words, stopwords are matched
+	// against its output so it should lowercase
+	Tokenizer Tokenizer
+	// Nicknames maps the other names participants are mentioned by to their
+	// name, e.g. "Al" to "Alice Smith" for "@Al", a leading @ is ignored
+	Nicknames map[string]string
+	// MediaRoot is the folder of the export, voice message durations are
+	// read from the files there when it is set
+	MediaRoot string
+	// Stopwords are the words left out of word and phrase counts, nil
+	// counts every word
+	Stopwords []string
+}
+
+// DefaultOptions returns the options AnalyzeMessages uses
+func DefaultOptions() Options {
+	return Options{
+		Aliases:        make(map[string]string),
+		Nicknames:      make(map[string]string),
+		Display:        DisplayUnique,
+		Location:       time.Local,
+		ResponseCutoff: 6 * time.Hour,
+		SessionGap:     time.Hour,
+		NGramSizes:     []int{2, 3},
+		Tokenizer:      NewTokenizer(),
+		Stopwords:      append([]string{}, builtinStopwords["en"]...),
+	}
+}
+
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.Local
+	}
+
+	return o.Location
+}
+
+func (o Options) tokenizer() Tokenizer {
+	if o.Tokenizer == nil {
+		return NewTokenizer()
 	}
+
+	return o.Tokenizer
 }
 
-func nameToFirstName(s string) string {
-	firstName := strings.Split(s, " ")[0]
-	return firstName
+// participant returns the analysis of the named participant, creating it
+// for senders and reactors missing from the participant list
+func (a *Analysis) participant(name string) *ParticipantAnalysis {
+	name = a.Participants.Add(name)
+	if _, ok := a.ParticipantAnalyses[name]; !ok {
+		a.ParticipantAnalyses[name] = newParticipantAnalysis()
+	}
+
+	return a.ParticipantAnalyses[name]
 }
 
 // AnalyzeMessages analyzes the message blob and returns the results
 func AnalyzeMessages(b Blob) Analysis {
-	a := newAnalysis()
+	return AnalyzeMessagesWithOptions(b, DefaultOptions())
+}
+
+// AnalyzeMessagesWithOptions analyzes the message blob with the options
+// and returns the results
+func AnalyzeMessagesWithOptions(b Blob, opts Options) Analysis {
+	a := newAnalysis(opts)
 	for _, p := range b.Participants {
-		a.ParticipantAnalyses[nameToFirstName(p.Name)] = newParticipantAnalysis()
+		a.participant(p.Name)
 	}
 
 	for _, m := range b.Messages {
@@ -157,6 +471,7 @@ func AnalyzeMessages(b Blob) Analysis {
 			fmt.Printf("analyzing message failed: %v", err)
 		}
 	}
+	a.Finish()
 
 	return a
 }
@@ -171,26 +486,50 @@ func AnalyzeMessage(a *Analysis, m Message) error {
 				a.Reactions[r.Reaction] = 1
 			}
 
-			if _, ok := a.ParticipantAnalyses[nameToFirstName(r.Actor)].Reactions[r.Reaction]; ok {
-				a.ParticipantAnalyses[nameToFirstName(r.Actor)].Reactions[r.Reaction]++
+			if _, ok := a.participant(r.Actor).Reactions[r.Reaction]; ok {
+				a.participant(r.Actor).Reactions[r.Reaction]++
 			} else {
-				a.ParticipantAnalyses[nameToFirstName(r.Actor)].Reactions[r.Reaction] = 1
+				a.participant(r.Actor).Reactions[r.Reaction] = 1
 			}
 		}
 	}
+	a.analyzeReactionsReceived(m)
 
 	a.MessageCount++
-	a.ParticipantAnalyses[nameToFirstName(m.SenderName)].MessageCount++
+	a.participant(m.SenderName).MessageCount++
+
+	sent := MessageTime(m, a.Options.location())
+	a.Activity.add(sent)
+	a.participant(m.SenderName).Activity.add(sent)
+
+	a.analyzeMedia(m, sent)
+	a.analyzeCall(m, sent)
+	a.analyzeMembership(m, sent)
+	for _, event := range m.Events() {
+		a.Events[event]++
+		a.participant(m.SenderName).Events[event]++
+	}
+	a.analyzeLinks(m)
 
-	if strings.Contains(m.Content, "sent a photo.") {
-		return nil
+	if len(m.Events()) == 0 {
+		current := sentMessage{
+			Sender:      a.Participants.Canonical(m.SenderName),
+			TimestampMs: m.TimestampMs,
+		}
+		if a.previous != nil {
+			a.analyzeResponse(*a.previous, current)
+		}
+		a.previous = &current
+		a.analyzeSession(current)
 	}
-	if strings.Contains(m.Content, "sent an attachment.") {
+
+	// the content of attachments and events is generated by Messenger,
+	// e.g. "sent a photo.", and isn't counted as words
+	if m.HasAttachments() || len(m.Events()) > 0 {
 		return nil
 	}
 	if m.Sticker != nil {
-		stickerID := strings.Split(m.Sticker.URI, "_n_")[1]
-		stickerID = strings.Split(stickerID, ".")[0]
+		stickerID := stickerURIToID(m.Sticker.URI)
 
 		if stickerID == "369239263222822" {
 			return nil
@@ -202,26 +541,26 @@ func AnalyzeMessage(a *Analysis, m Message) error {
 			a.Stickers[stickerID] = 1
 		}
 
-		if _, ok := a.ParticipantAnalyses[nameToFirstName(m.SenderName)].Stickers[stickerID]; ok {
-			a.ParticipantAnalyses[nameToFirstName(m.SenderName)].Stickers[stickerID]++
+		if _, ok := a.participant(m.SenderName).Stickers[stickerID]; ok {
+			a.participant(m.SenderName).Stickers[stickerID]++
 		} else {
-			a.ParticipantAnalyses[nameToFirstName(m.SenderName)].Stickers[stickerID] = 1
+			a.participant(m.SenderName).Stickers[stickerID] = 1
 		}
 
 		return nil
 	}
 
-	reg, err := regexp.Compile("[^a-zA-Z0-9@':]+")
-	if err != nil {
-		return errors.Wrap(err, "regex failed to compile")
-	}
-	words := reg.Split(strings.ToLower(m.Content), -1)
+	a.analyzeEmojis(m.SenderName, m.Content)
+	words := a.Options.tokenizer().Tokenize(removeLinks(m.Content))
+	a.analyzePhrases(m.SenderName, words)
+	a.analyzeMentions(m.SenderName, words)
+	a.analyzeText(m.SenderName, m.Content, words)
 	for _, word := range words {
-		if len(word) <= 1 {
+		if isShortWord(word) {
 			continue
 		}
 
-		if _, ok := ignoreWordMap[word]; ok {
+		if _, ok := a.stopwords[word]; ok {
 			continue
 		}
 
@@ -232,10 +571,10 @@ func AnalyzeMessage(a *Analysis, m Message) error {
 				a.Mentions[word] = 1
 			}
 
-			if _, ok := a.ParticipantAnalyses[nameToFirstName(m.SenderName)].Mentions[word]; ok {
-				a.ParticipantAnalyses[nameToFirstName(m.SenderName)].Mentions[word]++
+			if _, ok := a.participant(m.SenderName).Mentions[word]; ok {
+				a.participant(m.SenderName).Mentions[word]++
 			} else {
-				a.ParticipantAnalyses[nameToFirstName(m.SenderName)].Mentions[word] = 1
+				a.participant(m.SenderName).Mentions[word] = 1
 			}
 		}
 
@@ -245,10 +584,10 @@ func AnalyzeMessage(a *Analysis, m Message) error {
 			a.Words[word] = 1
 		}
 
-		if _, ok := a.ParticipantAnalyses[nameToFirstName(m.SenderName)].Words[word]; ok {
-			a.ParticipantAnalyses[nameToFirstName(m.SenderName)].Words[word]++
+		if _, ok := a.participant(m.SenderName).Words[word]; ok {
+			a.participant(m.SenderName).Words[word]++
 		} else {
-			a.ParticipantAnalyses[nameToFirstName(m.SenderName)].Words[word] = 1
+			a.participant(m.SenderName).Words[word] = 1
 		}
 	}
 
@@ -278,51 +617,167 @@ func (s StringFreqs) Less(i, j int) bool {
 
 // SortedAnalysis contains the aggregate analysis with the fields sorted
 type SortedAnalysis struct {
+	Participants              []string
+	DisplayNames              map[string]string
+	Aliases                   map[string]string
 	SortedParticipantAnalyses map[string]*SortedParticipantAnalysis
 	Stickers                  StringFreqs
 	Words                     StringFreqs
 	Reactions                 StringFreqs
 	Mentions                  StringFreqs
+	Media                     StringFreqs
+	Events                    StringFreqs
+	Activity                  SortedActivity
+	ResponseTimes             ResponseStats
+	Sessions                  SortedSessions
+	Phrases                   map[int]StringFreqs
+	NGramSizes                []int
+	Emojis                    StringFreqs
+	ReactionMatrix            map[string]map[string]int
+	MostReacted               []ReactedMessage
+	Interactions              InteractionGraph
+	UnresolvedMentions        StringFreqs
+	Domains                   StringFreqs
+	Links                     []SharedLink
+	MediaActivity             map[string]SortedActivity
+	AudioDuration             time.Duration
+	AudioMeasured             int
+	Calls                     SortedCalls
+	Membership                SortedMembership
+	Stopwords                 []string
 	MessageCount              int
 }
 
 // SortedParticipantAnalysis contains the sorted values of participants
 type SortedParticipantAnalysis struct {
-	Stickers     StringFreqs
-	Words        StringFreqs
-	Reactions    StringFreqs
-	Mentions     StringFreqs
-	MessageCount int
+	Stickers        StringFreqs
+	Words           StringFreqs
+	Reactions       StringFreqs
+	Mentions        StringFreqs
+	Media           StringFreqs
+	Events          StringFreqs
+	Activity        SortedActivity
+	ResponseTimes   ResponseStats
+	ResponseTimesTo map[string]ResponseStats
+	Phrases         map[int]StringFreqs
+	Emojis          StringFreqs
+	MessageCount    int
+
+	ReactionsReceived StringFreqs
+	ReactionsFrom     StringFreqs
+	MostReacted       []ReactedMessage
+
+	MentionsOf         StringFreqs
+	UnresolvedMentions StringFreqs
+
+	Domains   StringFreqs
+	LinkCount int
+
+	MediaActivity map[string]SortedActivity
+	AudioDuration time.Duration
+	AudioMeasured int
+
+	Calls SortedCalls
+
+	Tenures    []Tenure
+	DaysInChat float64
+
+	Metrics     Metrics
+	Distinctive []WordScore
+
+	ConversationsStarted int
+	ConversationsEnded   int
 }
 
 func newSortedAnalysis() SortedAnalysis {
 	return SortedAnalysis{
+		Participants:              []string{},
+		DisplayNames:              make(map[string]string),
+		Aliases:                   make(map[string]string),
 		SortedParticipantAnalyses: make(map[string]*SortedParticipantAnalysis),
 		Stickers:                  StringFreqs{},
 		Words:                     StringFreqs{},
 		Reactions:                 StringFreqs{},
 		Mentions:                  StringFreqs{},
+		Media:                     StringFreqs{},
+		Events:                    StringFreqs{},
+		Phrases:                   make(map[int]StringFreqs),
+		NGramSizes:                []int{},
+		Emojis:                    StringFreqs{},
+		ReactionMatrix:            make(map[string]map[string]int),
+		MostReacted:               []ReactedMessage{},
+		Interactions:              InteractionGraph{Nodes: []InteractionNode{}, Edges: []InteractionEdge{}},
+		UnresolvedMentions:        StringFreqs{},
+		Domains:                   StringFreqs{},
+		Links:                     []SharedLink{},
+		MediaActivity:             make(map[string]SortedActivity),
+		Membership:                SortedMembership{Events: []MembershipEvent{}, Tenures: make(map[string][]Tenure)},
+		Stopwords:                 []string{},
 		MessageCount:              0,
 	}
 }
 
 func newSortedParticipantAnalysis() *SortedParticipantAnalysis {
 	return &SortedParticipantAnalysis{
-		Stickers:     StringFreqs{},
-		Words:        StringFreqs{},
-		Reactions:    StringFreqs{},
-		Mentions:     StringFreqs{},
-		MessageCount: 0,
+		Stickers:        StringFreqs{},
+		Words:           StringFreqs{},
+		Reactions:       StringFreqs{},
+		Mentions:        StringFreqs{},
+		Media:           StringFreqs{},
+		Events:          StringFreqs{},
+		ResponseTimesTo: make(map[string]ResponseStats),
+		Phrases:         make(map[int]StringFreqs),
+		Emojis:          StringFreqs{},
+		MessageCount:    0,
+
+		ReactionsReceived: StringFreqs{},
+		ReactionsFrom:     StringFreqs{},
+		MostReacted:       []ReactedMessage{},
+
+		MentionsOf:         StringFreqs{},
+		UnresolvedMentions: StringFreqs{},
+
+		Domains:   StringFreqs{},
+		LinkCount: 0,
+
+		MediaActivity: make(map[string]SortedActivity),
+
+		Tenures: []Tenure{},
+
+		Distinctive: []WordScore{},
 	}
 }
 
 // SortAnalysis returns the sorted analysis which each map sorted
 func SortAnalysis(a Analysis) SortedAnalysis {
 	s := newSortedAnalysis()
+	s.Participants = a.Participants.Names()
+	s.DisplayNames = a.Participants.DisplayNames(a.Options.Display)
+	s.Aliases = a.Participants.Aliases()
 	s.Stickers = MapToSortedStringFreqs(a.Stickers)
 	s.Words = MapToSortedStringFreqs(a.Words)
 	s.Reactions = MapToSortedStringFreqs(a.Reactions)
 	s.Mentions = MapToSortedStringFreqs(a.Mentions)
+	s.Media = MapToSortedStringFreqs(a.Media)
+	s.Events = MapToSortedStringFreqs(a.Events)
+	s.Activity = sortActivity(a.Activity)
+	s.ResponseTimes = NewResponseStats(a.ResponseTimes)
+	s.Sessions = sortSessions(a.Sessions)
+	s.Phrases = sortPhrases(a.Phrases)
+	s.NGramSizes = phraseSizes(a.Options.NGramSizes)
+	s.Emojis = MapToSortedStringFreqs(a.Emojis)
+	s.ReactionMatrix = copyMatrix(a.ReactionMatrix)
+	s.MostReacted = sortMostReacted(a.MostReacted)
+	s.Interactions = newInteractionGraph(a)
+	s.UnresolvedMentions = MapToSortedStringFreqs(a.UnresolvedMentions)
+	s.Domains = MapToSortedStringFreqs(a.Domains)
+	s.Links = sortLinks(a.Links)
+	s.MediaActivity = sortMediaActivity(a.MediaActivity)
+	s.AudioDuration = a.AudioDuration
+	s.AudioMeasured = a.AudioMeasured
+	s.Calls = sortCalls(a.Calls)
+	s.Membership = sortMembership(a)
+	s.Stopwords = sortStopwords(a.stopwords)
 	s.MessageCount = a.MessageCount
 
 	for k := range a.ParticipantAnalyses {
@@ -331,6 +786,32 @@ func SortAnalysis(a Analysis) SortedAnalysis {
 		s.SortedParticipantAnalyses[k].Words = MapToSortedStringFreqs(a.ParticipantAnalyses[k].Words)
 		s.SortedParticipantAnalyses[k].Reactions = MapToSortedStringFreqs(a.ParticipantAnalyses[k].Reactions)
 		s.SortedParticipantAnalyses[k].Mentions = MapToSortedStringFreqs(a.ParticipantAnalyses[k].Mentions)
+		s.SortedParticipantAnalyses[k].Media = MapToSortedStringFreqs(a.ParticipantAnalyses[k].Media)
+		s.SortedParticipantAnalyses[k].Events = MapToSortedStringFreqs(a.ParticipantAnalyses[k].Events)
+		s.SortedParticipantAnalyses[k].Activity = sortActivity(a.ParticipantAnalyses[k].Activity)
+		s.SortedParticipantAnalyses[k].ResponseTimes = NewResponseStats(a.ParticipantAnalyses[k].ResponseTimes)
+		s.SortedParticipantAnalyses[k].Phrases = sortPhrases(a.ParticipantAnalyses[k].Phrases)
+		s.SortedParticipantAnalyses[k].Emojis = MapToSortedStringFreqs(a.ParticipantAnalyses[k].Emojis)
+		s.SortedParticipantAnalyses[k].ConversationsStarted = a.ParticipantAnalyses[k].ConversationsStarted
+		s.SortedParticipantAnalyses[k].ConversationsEnded = a.ParticipantAnalyses[k].ConversationsEnded
+		s.SortedParticipantAnalyses[k].ReactionsReceived = MapToSortedStringFreqs(a.ParticipantAnalyses[k].ReactionsReceived)
+		s.SortedParticipantAnalyses[k].ReactionsFrom = MapToSortedStringFreqs(a.ParticipantAnalyses[k].ReactionsFrom)
+		s.SortedParticipantAnalyses[k].MostReacted = sortMostReacted(a.ParticipantAnalyses[k].MostReacted)
+		s.SortedParticipantAnalyses[k].MentionsOf = MapToSortedStringFreqs(a.ParticipantAnalyses[k].MentionsOf)
+		s.SortedParticipantAnalyses[k].UnresolvedMentions = MapToSortedStringFreqs(a.ParticipantAnalyses[k].UnresolvedMentions)
+		s.SortedParticipantAnalyses[k].Domains = MapToSortedStringFreqs(a.ParticipantAnalyses[k].Domains)
+		s.SortedParticipantAnalyses[k].LinkCount = a.ParticipantAnalyses[k].LinkCount
+		s.SortedParticipantAnalyses[k].MediaActivity = sortMediaActivity(a.ParticipantAnalyses[k].MediaActivity)
+		s.SortedParticipantAnalyses[k].AudioDuration = a.ParticipantAnalyses[k].AudioDuration
+		s.SortedParticipantAnalyses[k].AudioMeasured = a.ParticipantAnalyses[k].AudioMeasured
+		s.SortedParticipantAnalyses[k].Calls = sortCalls(a.ParticipantAnalyses[k].Calls)
+		s.SortedParticipantAnalyses[k].Tenures = s.Membership.Tenures[k]
+		s.SortedParticipantAnalyses[k].DaysInChat = DaysInChat(s.Membership.Tenures[k])
+		s.SortedParticipantAnalyses[k].Metrics = newMetrics(a.ParticipantAnalyses[k], s.SortedParticipantAnalyses[k], a.MessageCount)
+		s.SortedParticipantAnalyses[k].Distinctive = distinctiveWords(a, k)
+		for to, latencies := range a.ParticipantAnalyses[k].ResponseTimesTo {
+			s.SortedParticipantAnalyses[k].ResponseTimesTo[to] = NewResponseStats(latencies)
+		}
 		s.SortedParticipantAnalyses[k].MessageCount = a.ParticipantAnalyses[k].MessageCount
 	}
 
@@ -0,0 +1,64 @@
+package message
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Metrics are a participant's counts normalized so members who wrote for
+// different lengths of time, or in different styles, can be compared.
+// Per message metrics only count text messages. The type-token ratio
+// falls as people write more, so compare it between similar word counts.
+type Metrics struct {
+	MessageShare         float64
+	WordsPerMessage      float64
+	CharactersPerMessage float64
+	Vocabulary           int
+	TypeTokenRatio       float64
+	ActiveDays           int
+	MessagesPerActiveDay float64
+	MessagesPerDayInChat float64
+}
+
+// analyzeText counts the words and characters of a text message and the
+// distinct words its sender uses, stopwords included
+func (a *Analysis) analyzeText(sender string, content string, words []string) {
+	if strings.TrimSpace(content) == "" {
+		return
+	}
+
+	pa := a.participant(sender)
+	pa.TextMessages++
+	pa.WordCount += len(words)
+	pa.CharacterCount += utf8.RuneCountInString(content)
+	for _, word := range words {
+		pa.Vocabulary[word] = true
+	}
+}
+
+// newMetrics derives the participant's metrics, total is the number of
+// messages in the thread
+func newMetrics(pa *ParticipantAnalysis, s *SortedParticipantAnalysis, total int) Metrics {
+	m := Metrics{
+		Vocabulary: len(pa.Vocabulary),
+		ActiveDays: len(s.Activity.Days),
+	}
+	if total > 0 {
+		m.MessageShare = float64(pa.MessageCount) / float64(total)
+	}
+	if pa.TextMessages > 0 {
+		m.WordsPerMessage = float64(pa.WordCount) / float64(pa.TextMessages)
+		m.CharactersPerMessage = float64(pa.CharacterCount) / float64(pa.TextMessages)
+	}
+	if pa.WordCount > 0 {
+		m.TypeTokenRatio = float64(len(pa.Vocabulary)) / float64(pa.WordCount)
+	}
+	if m.ActiveDays > 0 {
+		m.MessagesPerActiveDay = float64(pa.MessageCount) / float64(m.ActiveDays)
+	}
+	if s.DaysInChat > 0 {
+		m.MessagesPerDayInChat = float64(pa.MessageCount) / s.DaysInChat
+	}
+
+	return m
+}
@@ -0,0 +1,154 @@
+package message
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DisplayStrategy decides how participants are named in charts
+type DisplayStrategy string
+
+// Display strategies, DisplayUnique uses first names unless two
+// participants share one
+const (
+	DisplayFullName  DisplayStrategy = "full"
+	DisplayFirstName DisplayStrategy = "first"
+	DisplayUnique    DisplayStrategy = "unique"
+)
+
+// ParseDisplayStrategy returns the strategy named s
+func ParseDisplayStrategy(s string) (DisplayStrategy, error) {
+	switch d := DisplayStrategy(s); d {
+	case DisplayFullName, DisplayFirstName, DisplayUnique:
+		return d, nil
+	}
+
+	return "", errors.Errorf("unknown display strategy %s, expected full, first or unique", s)
+}
+
+// Registry resolves the names found in an export to participant
+// identities. Participants are keyed by their full name, aliases merge
+// other names, e.g. a renamed account, into a participant.
+type Registry struct {
+	aliases map[string]string
+	known   map[string]bool
+	names   []string
+}
+
+// NewRegistry returns an empty registry that merges every alias into the
+// name it maps to
+func NewRegistry(aliases map[string]string) *Registry {
+	r := &Registry{
+		aliases: make(map[string]string),
+		known:   make(map[string]bool),
+		names:   []string{},
+	}
+	for alias, name := range aliases {
+		r.aliases[alias] = name
+	}
+
+	return r
+}
+
+// Canonical returns the participant name is merged into, following
+// chains of aliases
+func (r *Registry) Canonical(name string) string {
+	name = strings.TrimSpace(name)
+	for i := 0; i <= len(r.aliases); i++ {
+		next, ok := r.aliases[name]
+		if !ok || next == name {
+			break
+		}
+		name = next
+	}
+
+	return name
+}
+
+// Add registers the participant, returning its canonical name
+func (r *Registry) Add(name string) string {
+	name = r.Canonical(name)
+	if !r.known[name] {
+		r.known[name] = true
+		r.names = append(r.names, name)
+	}
+
+	return name
+}
+
+// Names returns the canonical name of every participant in the order
+// they were first seen
+func (r *Registry) Names() []string {
+	return append([]string{}, r.names...)
+}
+
+// Aliases returns a copy of the alias map
+func (r *Registry) Aliases() map[string]string {
+	aliases := make(map[string]string)
+	for alias, name := range r.aliases {
+		aliases[alias] = name
+	}
+
+	return aliases
+}
+
+// DisplayNames maps every participant to its name under the strategy
+func (r *Registry) DisplayNames(strategy DisplayStrategy) map[string]string {
+	firstNames := make(map[string]int)
+	for _, name := range r.names {
+		firstNames[nameToFirstName(name)]++
+	}
+
+	display := make(map[string]string)
+	for _, name := range r.names {
+		switch strategy {
+		case DisplayFirstName:
+			display[name] = nameToFirstName(name)
+		case DisplayUnique:
+			if firstNames[nameToFirstName(name)] == 1 {
+				display[name] = nameToFirstName(name)
+			} else {
+				display[name] = name
+			}
+		default:
+			display[name] = name
+		}
+	}
+
+	return display
+}
+
+// ResolveName returns the participant a query names, matching the
+// canonical name, a display name or an alias
+func (s SortedAnalysis) ResolveName(query string) (string, bool) {
+	if _, ok := s.SortedParticipantAnalyses[query]; ok {
+		return query, true
+	}
+	for name, display := range s.DisplayNames {
+		if strings.EqualFold(display, query) {
+			return name, true
+		}
+	}
+	if name, ok := s.Aliases[query]; ok {
+		if _, ok := s.SortedParticipantAnalyses[name]; ok {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// DisplayName returns how the participant is named in charts
+func (s SortedAnalysis) DisplayName(name string) string {
+	if display, ok := s.DisplayNames[name]; ok {
+		return display
+	}
+
+	return name
+}
+
+func nameToFirstName(s string) string {
+	firstName := strings.Split(s, " ")[0]
+	return firstName
+}
@@ -0,0 +1,94 @@
+package message
+
+import (
+	"sort"
+	"strings"
+)
+
+// analyzePhrases counts every n-gram of the configured sizes in the
+// message's words. Stopwords may sit inside a phrase, as in "see you
+// tomorrow", but a phrase can't start or end with one or with a single
+// character.
+func (a *Analysis) analyzePhrases(sender string, words []string) {
+	tokens := []string{}
+	for _, word := range words {
+		if word != "" {
+			tokens = append(tokens, word)
+		}
+	}
+
+	for _, n := range a.Options.NGramSizes {
+		if n < 2 {
+			continue
+		}
+		for i := 0; i+n <= len(tokens); i++ {
+			if !a.isPhraseBoundary(tokens[i]) || !a.isPhraseBoundary(tokens[i+n-1]) {
+				continue
+			}
+			phrase := strings.Join(tokens[i:i+n], " ")
+
+			if _, ok := a.Phrases[n]; !ok {
+				a.Phrases[n] = make(map[string]int)
+			}
+			a.Phrases[n][phrase]++
+
+			pa := a.participant(sender)
+			if _, ok := pa.Phrases[n]; !ok {
+				pa.Phrases[n] = make(map[string]int)
+			}
+			pa.Phrases[n][phrase]++
+		}
+	}
+}
+
+// isPhraseBoundary reports whether a phrase may start or end with the word
+func (a *Analysis) isPhraseBoundary(word string) bool {
+	return !isShortWord(word) && !a.stopwords[word]
+}
+
+func sortPhrases(phrases map[int]map[string]int) map[int]StringFreqs {
+	sorted := make(map[int]StringFreqs)
+	for n, counts := range phrases {
+		sorted[n] = MapToSortedStringFreqs(counts)
+	}
+
+	return sorted
+}
+
+// phraseSizes returns the distinct phrase lengths that are counted, in order
+func phraseSizes(sizes []int) []int {
+	counted := []int{}
+	seen := make(map[int]bool)
+	for _, n := range sizes {
+		if n < 2 || seen[n] {
+			continue
+		}
+		seen[n] = true
+		counted = append(counted, n)
+	}
+	sort.Ints(counted)
+
+	return counted
+}
+
+// HasNGramSize reports whether phrases of n words were counted
+func (s SortedAnalysis) HasNGramSize(n int) bool {
+	for _, size := range s.NGramSizes {
+		if size == n {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllPhrases merges the phrases of every size into one frequency list
+func AllPhrases(phrases map[int]StringFreqs) StringFreqs {
+	all := StringFreqs{}
+	for _, sfs := range phrases {
+		all = append(all, sfs...)
+	}
+	sort.Stable(all)
+
+	return all
+}
@@ -0,0 +1,99 @@
+package message
+
+import (
+	"sort"
+	"time"
+)
+
+// mostReactedKept is how many of the most reacted messages are kept for
+// the thread and for each participant
+const mostReactedKept = 100
+
+// ReactedMessage is a message and how many reactions it received
+type ReactedMessage struct {
+	Sender    string
+	Content   string
+	Time      time.Time
+	Reactions int
+}
+
+// analyzeReactionsReceived credits the message's reactions to its sender,
+// by reaction and by who reacted, and ranks the message against the most
+// reacted ones
+func (a *Analysis) analyzeReactionsReceived(m Message) {
+	if m.Reactions == nil || len(*m.Reactions) == 0 {
+		return
+	}
+
+	author := a.participant(m.SenderName)
+	sender := a.Participants.Canonical(m.SenderName)
+	for _, r := range *m.Reactions {
+		actor := a.Participants.Add(r.Actor)
+		author.ReactionsReceived[r.Reaction]++
+		author.ReactionsFrom[actor]++
+
+		if _, ok := a.ReactionMatrix[actor]; !ok {
+			a.ReactionMatrix[actor] = make(map[string]int)
+		}
+		a.ReactionMatrix[actor][sender]++
+	}
+
+	reacted := ReactedMessage{
+		Sender:    sender,
+		Content:   m.Content,
+		Time:      MessageTime(m, a.Options.location()),
+		Reactions: len(*m.Reactions),
+	}
+	a.MostReacted = keepMostReacted(append(a.MostReacted, reacted))
+	author.MostReacted = keepMostReacted(append(author.MostReacted, reacted))
+}
+
+// keepMostReacted trims the messages to the most reacted ones once the
+// list has grown to twice what is kept, so ranking stays cheap
+func keepMostReacted(messages []ReactedMessage) []ReactedMessage {
+	if len(messages) < 2*mostReactedKept {
+		return messages
+	}
+
+	return sortMostReacted(messages)[:mostReactedKept]
+}
+
+// sortMostReacted orders messages by reactions, ties go to the earlier
+// message
+func sortMostReacted(messages []ReactedMessage) []ReactedMessage {
+	sorted := append([]ReactedMessage{}, messages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Reactions != sorted[j].Reactions {
+			return sorted[i].Reactions > sorted[j].Reactions
+		}
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+	if len(sorted) > mostReactedKept {
+		sorted = sorted[:mostReactedKept]
+	}
+
+	return sorted
+}
+
+// ReactionsReceivedCount returns the total reactions the participant's
+// messages received
+func (s *SortedParticipantAnalysis) ReactionsReceivedCount() int {
+	total := 0
+	for _, v := range s.ReactionsReceived {
+		total += v.Freq
+	}
+
+	return total
+}
+
+func copyMatrix(m map[string]map[string]int) map[string]map[string]int {
+	copied := make(map[string]map[string]int)
+	for from, row := range m {
+		copied[from] = make(map[string]int)
+		for to, count := range row {
+			copied[from][to] = count
+		}
+	}
+
+	return copied
+}
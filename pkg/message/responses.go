@@ -0,0 +1,109 @@
+package message
+
+import (
+	"sort"
+	"time"
+)
+
+// sentMessage is the sender and time of a message, kept to relate each
+// message to the one before it
+type sentMessage struct {
+	Sender      string
+	TimestampMs int64
+}
+
+// ResponseStats summarizes reply latencies in seconds
+type ResponseStats struct {
+	Count  int
+	Mean   float64
+	P25    float64
+	Median float64
+	P75    float64
+	P90    float64
+}
+
+// orderMessages returns the earlier and the later of two messages, the
+// messages may arrive in either order since exports list newest first
+func orderMessages(a, b sentMessage) (sentMessage, sentMessage) {
+	if b.TimestampMs < a.TimestampMs {
+		return b, a
+	}
+
+	return a, b
+}
+
+// analyzeResponse records how long the later of the two adjacent messages
+// took to answer the earlier one. Gaps longer than the response cutoff are
+// a new conversation rather than a reply and are skipped.
+func (a *Analysis) analyzeResponse(previous, current sentMessage) {
+	earlier, later := orderMessages(previous, current)
+	if earlier.Sender == later.Sender {
+		return
+	}
+
+	gap := time.Duration(later.TimestampMs-earlier.TimestampMs) * time.Millisecond
+	if a.Options.ResponseCutoff > 0 && gap > a.Options.ResponseCutoff {
+		return
+	}
+
+	seconds := int64(gap / time.Second)
+	a.ResponseTimes = append(a.ResponseTimes, seconds)
+
+	responder := a.participant(later.Sender)
+	responder.ResponseTimes = append(responder.ResponseTimes, seconds)
+	responder.ResponseTimesTo[earlier.Sender] = append(responder.ResponseTimesTo[earlier.Sender], seconds)
+}
+
+// NewResponseStats summarizes the latencies
+func NewResponseStats(latencies []int64) ResponseStats {
+	if len(latencies) == 0 {
+		return ResponseStats{}
+	}
+
+	sorted := append([]int64{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	total := int64(0)
+	for _, l := range sorted {
+		total += l
+	}
+
+	return ResponseStats{
+		Count:  len(sorted),
+		Mean:   float64(total) / float64(len(sorted)),
+		P25:    percentile(sorted, 25),
+		Median: percentile(sorted, 50),
+		P75:    percentile(sorted, 75),
+		P90:    percentile(sorted, 90),
+	}
+}
+
+// Stat returns the named statistic: mean, p25, median, p75 or p90
+func (s ResponseStats) Stat(name string) (float64, bool) {
+	switch name {
+	case "mean":
+		return s.Mean, true
+	case "p25":
+		return s.P25, true
+	case "median", "p50":
+		return s.Median, true
+	case "p75":
+		return s.P75, true
+	case "p90":
+		return s.P90, true
+	}
+
+	return 0, false
+}
+
+// percentile returns the nearest rank percentile of the sorted values
+func percentile(sorted []int64, p int) float64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return float64(sorted[rank-1])
+}
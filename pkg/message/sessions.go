@@ -0,0 +1,114 @@
+package message
+
+import (
+	"time"
+)
+
+// Sessions contains the conversations found in the thread, a conversation
+// ends once nobody has written for the session gap
+type Sessions struct {
+	Count     int
+	Messages  int
+	Durations []int64
+	Starters  map[string]int
+	Enders    map[string]int
+}
+
+// SortedSessions contains the conversation statistics with the starters
+// and enders sorted, lengths are in seconds
+type SortedSessions struct {
+	Count           int
+	AverageLength   float64
+	MedianLength    float64
+	AverageMessages float64
+	Starters        StringFreqs
+	Enders          StringFreqs
+}
+
+// session is the conversation currently being read
+type session struct {
+	First    sentMessage
+	Last     sentMessage
+	Messages int
+}
+
+func newSessions() *Sessions {
+	return &Sessions{
+		Durations: []int64{},
+		Starters:  make(map[string]int),
+		Enders:    make(map[string]int),
+	}
+}
+
+// analyzeSession adds the message to the current conversation, or closes
+// it and starts a new one when the message is further than the session
+// gap from it. Messages may arrive newest or oldest first.
+func (a *Analysis) analyzeSession(current sentMessage) {
+	if s := a.session; s != nil {
+		gap := int64(0)
+		if current.TimestampMs > s.Last.TimestampMs {
+			gap = current.TimestampMs - s.Last.TimestampMs
+		} else if current.TimestampMs < s.First.TimestampMs {
+			gap = s.First.TimestampMs - current.TimestampMs
+		}
+
+		if a.Options.SessionGap <= 0 || time.Duration(gap)*time.Millisecond <= a.Options.SessionGap {
+			if current.TimestampMs >= s.Last.TimestampMs {
+				s.Last = current
+			}
+			if current.TimestampMs < s.First.TimestampMs {
+				s.First = current
+			}
+			s.Messages++
+			return
+		}
+
+		a.closeSession()
+	}
+
+	a.session = &session{
+		First:    current,
+		Last:     current,
+		Messages: 1,
+	}
+}
+
+func (a *Analysis) closeSession() {
+	s := a.session
+	if s == nil {
+		return
+	}
+	a.session = nil
+
+	a.Sessions.Count++
+	a.Sessions.Messages += s.Messages
+	a.Sessions.Durations = append(a.Sessions.Durations, (s.Last.TimestampMs-s.First.TimestampMs)/1000)
+	a.Sessions.Starters[s.First.Sender]++
+	a.Sessions.Enders[s.Last.Sender]++
+	a.participant(s.First.Sender).ConversationsStarted++
+	a.participant(s.Last.Sender).ConversationsEnded++
+}
+
+// Finish closes the conversation still open after the last message, call
+// it once every message has been passed to AnalyzeMessage
+func (a *Analysis) Finish() {
+	a.closeSession()
+}
+
+func sortSessions(s *Sessions) SortedSessions {
+	sorted := SortedSessions{
+		Count:    s.Count,
+		Starters: MapToSortedStringFreqs(s.Starters),
+		Enders:   MapToSortedStringFreqs(s.Enders),
+	}
+	if s.Count == 0 {
+		return sorted
+	}
+
+	lengths := NewResponseStats(s.Durations)
+	sorted.AverageLength = lengths.Mean
+	sorted.MedianLength = lengths.Median
+	sorted.AverageMessages = float64(s.Messages) / float64(s.Count)
+
+	return sorted
+}
@@ -0,0 +1,157 @@
+package message
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// builtinStopwords are the stopword lists shipped for each language,
+// keyed by ISO 639-1 code
+var builtinStopwords = map[string][]string{
+	"en": {"a", "about", "above", "after", "again", "against",
+		"all", "am", "an", "and", "any", "are", "aren't", "as", "at",
+		"be", "because", "been", "before", "being", "below", "between",
+		"both", "but", "by", "can't", "cannot", "could", "couldn't",
+		"did", "didn't", "do", "does", "doesn't", "doing", "don't",
+		"down", "during", "each", "few", "for", "from", "further", "had",
+		"hadn't", "has", "hasn't", "have", "haven't", "having", "he",
+		"he'd", "he'll", "he's", "her", "here", "here's", "hers", "herself",
+		"him", "himself", "his", "how", "how's", "i", "i'd", "i'll", "i'm",
+		"i've", "if", "in", "into", "is", "isn't", "it", "it's", "its",
+		"itself", "let's", "me", "more", "most", "mustn't", "my", "myself",
+		"no", "nor", "not", "of", "off", "on", "once", "only", "or", "other",
+		"ought", "our", "ours", "ourselves", "out", "over", "own", "same",
+		"shan't", "she", "she'd", "she'll", "she's", "should", "shouldn't",
+		"so", "some", "such", "than", "that", "that's", "the", "their",
+		"theirs", "them", "themselves", "then", "there", "there's", "these",
+		"they", "they'd", "they'll", "they're", "they've", "this", "those",
+		"through", "to", "too", "under", "until", "up", "very", "was", "wasn't",
+		"we", "we'd", "we'll", "we're", "we've", "were", "weren't", "what",
+		"what's", "when", "when's", "where", "where's", "which", "while",
+		"who", "who's", "whom", "why", "why's", "with", "won't", "would",
+		"wouldn't", "you", "you'd", "you'll", "you're", "you've", "your",
+		"yours", "yourself", "yourselves", "http", "https", "www", "com", "im"},
+	"es": {"a", "al", "algo", "algunos", "ante", "antes", "como", "con",
+		"contra", "cual", "cuando", "de", "del", "desde", "donde", "durante",
+		"e", "el", "ella", "ellas", "ellos", "en", "entre", "era", "es",
+		"esa", "esas", "ese", "eso", "esos", "esta", "estaba", "estas",
+		"este", "esto", "estos", "estoy", "fue", "ha", "hay", "la", "las",
+		"le", "les", "lo", "los", "me", "mi", "mis", "muy", "más", "nada",
+		"ni", "no", "nos", "nosotros", "o", "os", "otra", "otro", "para",
+		"pero", "poco", "por", "porque", "que", "qué", "se", "sea", "ser",
+		"si", "sí", "sin", "sobre", "son", "su", "sus", "también", "te",
+		"tengo", "ti", "tu", "tus", "tú", "un", "una", "uno", "unos", "y",
+		"ya", "yo", "él"},
+	"fr": {"à", "ai", "au", "aux", "avec", "c'est", "ce", "ces", "cette",
+		"dans", "de", "des", "du", "elle", "elles", "en", "est", "et", "eu",
+		"il", "ils", "j'ai", "je", "la", "le", "les", "leur", "lui", "ma",
+		"mais", "me", "mes", "moi", "mon", "même", "ne", "nos", "notre",
+		"nous", "on", "ou", "où", "par", "pas", "pour", "qu'il", "que",
+		"qui", "sa", "se", "ses", "son", "sont", "sur", "ta", "te", "tes",
+		"toi", "ton", "tu", "un", "une", "vos", "votre", "vous", "y", "été",
+		"être", "avoir", "fait", "ça", "si", "tout", "plus", "comme"},
+	"de": {"aber", "alle", "als", "am", "an", "auch", "auf", "aus", "bei",
+		"bin", "bis", "bist", "da", "dann", "das", "dass", "dein", "dem",
+		"den", "der", "des", "dich", "die", "dir", "doch", "du", "ein",
+		"eine", "einem", "einen", "einer", "er", "es", "für", "hab", "habe",
+		"hat", "hatte", "ich", "ihr", "im", "in", "ist", "ja", "jetzt",
+		"kann", "kein", "mal", "man", "mein", "mich", "mir", "mit", "nach",
+		"nicht", "noch", "nur", "oder", "schon", "sich", "sie", "sind", "so",
+		"um", "und", "uns", "von", "vor", "war", "was", "wenn", "wie", "wir",
+		"wird", "zu", "zum", "zur"},
+	"it": {"a", "ad", "al", "alla", "anche", "che", "chi", "ci", "come",
+		"con", "da", "dal", "del", "della", "di", "e", "è", "gli", "ha",
+		"hai", "ho", "i", "il", "in", "io", "la", "le", "lei", "lo", "loro",
+		"lui", "ma", "mi", "mia", "mio", "ne", "nel", "noi", "non", "o",
+		"per", "più", "quando", "quello", "questo", "se", "sei", "si", "sono",
+		"su", "sua", "suo", "ti", "tra", "tu", "tuo", "un", "una", "uno",
+		"voi"},
+	"pt": {"a", "ao", "as", "com", "como", "da", "das", "de", "do", "dos",
+		"e", "é", "ela", "ele", "eles", "em", "era", "essa", "esse", "esta",
+		"está", "este", "eu", "foi", "isso", "já", "lhe", "mais", "mas",
+		"me", "meu", "minha", "muito", "na", "não", "nas", "nem", "no",
+		"nos", "nós", "o", "os", "ou", "para", "pela", "pelo", "por", "que",
+		"se", "sem", "ser", "seu", "sua", "são", "também", "te", "tem",
+		"tu", "um", "uma", "você", "vocês"},
+	"nl": {"aan", "al", "als", "bij", "dan", "dat", "de", "die", "dit",
+		"een", "en", "er", "had", "heb", "heeft", "het", "hij", "hoe", "ik",
+		"in", "is", "je", "jij", "kan", "maar", "me", "met", "mij", "mijn",
+		"na", "naar", "niet", "nog", "nu", "of", "om", "ook", "op", "over",
+		"te", "tot", "uit", "van", "voor", "was", "wat", "we", "wel", "wij",
+		"wordt", "zal", "ze", "zij", "zijn", "zo"},
+}
+
+// StopwordLanguages returns the languages with a built in stopword list
+func StopwordLanguages() []string {
+	languages := []string{}
+	for language := range builtinStopwords {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	return languages
+}
+
+// BuiltinStopwords returns the built in stopword list of the language
+func BuiltinStopwords(language string) ([]string, error) {
+	words, ok := builtinStopwords[strings.ToLower(language)]
+	if !ok {
+		return nil, errors.Errorf("no stopwords for language %s, expected one of %s",
+			language, strings.Join(StopwordLanguages(), ", "))
+	}
+
+	return append([]string{}, words...), nil
+}
+
+// LoadStopwords reads a stopword file, one word per line with blank lines
+// and lines starting with # ignored
+func LoadStopwords(filepath string) ([]string, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read stopwords")
+	}
+	defer f.Close()
+
+	words := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read stopwords")
+	}
+
+	return words, nil
+}
+
+// stopwordSet runs every stopword through the tokenizer, so words are
+// matched in the form they are counted in, e.g. without accents
+func stopwordSet(words []string, tokenizer Tokenizer) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range words {
+		tokens := tokenizer.Tokenize(word)
+		if len(tokens) == 1 {
+			set[tokens[0]] = true
+		}
+	}
+
+	return set
+}
+
+func sortStopwords(set map[string]bool) []string {
+	words := []string{}
+	for word := range set {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	return words
+}
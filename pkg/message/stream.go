@@ -0,0 +1,187 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// StreamMessages decodes the message.json in r one token at a time. Every
+// participant is handed to onParticipant and every message to onMessage as
+// soon as it is decoded, so memory stays bounded no matter how large the
+// thread is. The returned Blob holds everything but the messages.
+func StreamMessages(r io.Reader, onParticipant func(Participant), onMessage func(Message) error) (Blob, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return Blob{}, err
+	}
+
+	header := make(map[string]json.RawMessage)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return Blob{}, errors.Wrap(err, "json failed to parse")
+		}
+		key, ok := t.(string)
+		if !ok {
+			return Blob{}, errors.Errorf("expected object key, got %v", t)
+		}
+
+		switch key {
+		case "participants":
+			var participants []Participant
+			if err := dec.Decode(&participants); err != nil {
+				return Blob{}, errors.Wrap(err, "failed to parse participants")
+			}
+			for _, p := range participants {
+				repairStrings(&p)
+				onParticipant(p)
+			}
+		case "messages":
+			if err := streamMessageArray(dec, onMessage); err != nil {
+				return Blob{}, err
+			}
+		default:
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return Blob{}, errors.Wrapf(err, "failed to parse %s", key)
+			}
+			header[key] = raw
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return Blob{}, err
+	}
+
+	// the remaining fields are small, round trip them into the Blob
+	var b Blob
+	dat, err := json.Marshal(header)
+	if err != nil {
+		return Blob{}, errors.Wrap(err, "failed to encode header")
+	}
+	if err := json.Unmarshal(dat, &b); err != nil {
+		return Blob{}, errors.Wrap(err, "json failed to parse")
+	}
+	repairStrings(&b)
+
+	return b, nil
+}
+
+func streamMessageArray(dec *json.Decoder, onMessage func(Message) error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		var m Message
+		if err := dec.Decode(&m); err != nil {
+			return errors.Wrap(err, "failed to parse message")
+		}
+		repairStrings(&m)
+
+		if err := onMessage(m); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return errors.Wrap(err, "json failed to parse")
+	}
+	if d, ok := t.(json.Delim); !ok || d != delim {
+		return errors.Errorf("expected %v, got %v", delim, t)
+	}
+
+	return nil
+}
+
+// AnalyzeStream analyzes every reader as a part of the same thread while
+// streaming it, the messages are never held in memory all at once
+func AnalyzeStream(opts Options, readers ...io.Reader) (Analysis, error) {
+	a := newAnalysis(opts)
+	for _, r := range readers {
+		err := analyzeReader(&a, r)
+		if err != nil {
+			return Analysis{}, err
+		}
+	}
+	a.Finish()
+
+	return a, nil
+}
+
+// AnalyzeExportStream finds the thread in the export paths, as accepted by
+// ParseExport, and analyzes its parts one at a time while streaming them.
+// The thread may be left empty when the export holds a single thread.
+func AnalyzeExportStream(opts Options, thread string, paths ...string) (Analysis, error) {
+	threads, closeExport, err := collectExport(paths...)
+	if err != nil {
+		return Analysis{}, err
+	}
+	defer closeExport()
+
+	t, err := findThreadFiles(threads, thread)
+	if err != nil {
+		return Analysis{}, err
+	}
+
+	a := newAnalysis(opts)
+	for _, part := range t.Parts {
+		err := analyzeExportFile(&a, part)
+		if err != nil {
+			return Analysis{}, errors.Wrapf(err, "failed to analyze %s", part.Name)
+		}
+	}
+	a.Finish()
+
+	return a, nil
+}
+
+func analyzeExportFile(a *Analysis, f exportFile) error {
+	r, err := f.Open()
+	if err != nil {
+		return errors.Wrap(err, "failed to open file")
+	}
+	defer r.Close()
+
+	// HTML pages can't be streamed, they are bounded by Facebook's page size
+	if f.HTML {
+		b, err := decodeHTMLBlob(r)
+		if err != nil {
+			return err
+		}
+		for _, p := range b.Participants {
+			a.participant(p.Name)
+		}
+		for _, m := range b.Messages {
+			analyzeStreamMessage(a, m)
+		}
+		return nil
+	}
+
+	return analyzeReader(a, r)
+}
+
+func analyzeReader(a *Analysis, r io.Reader) error {
+	_, err := StreamMessages(r, func(p Participant) {
+		a.participant(p.Name)
+	}, func(m Message) error {
+		analyzeStreamMessage(a, m)
+		return nil
+	})
+
+	return err
+}
+
+func analyzeStreamMessage(a *Analysis, m Message) {
+	err := AnalyzeMessage(a, m)
+	if err != nil {
+		fmt.Printf("analyzing message failed: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// benchMessages is the size of the generated thread, large enough that
+// holding every message at once shows up in the heap
+const benchMessages = 100000
+
+// writeLargeThread writes a single thread export holding n messages and
+// returns the export root
+func writeLargeThread(b *testing.B, n int) string {
+	root := b.TempDir()
+	dir := filepath.Join(root, "inbox", "bench_abc123")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		b.Fatal(err)
+	}
+
+	senders := []string{"Alice Smith", "Bob Jones", "Carol White"}
+	blob := Blob{
+		Title:      "Bench",
+		ThreadPath: "inbox/bench_abc123",
+		ThreadType: "RegularGroup",
+		Messages:   make([]Message, 0, n),
+	}
+	for _, s := range senders {
+		blob.Participants = append(blob.Participants, Participant{Name: s})
+	}
+
+	// exports list messages newest first
+	start := int64(1500000000000)
+	for i := n - 1; i >= 0; i-- {
+		m := Message{
+			SenderName:  senders[i%len(senders)],
+			TimestampMs: start + int64(i)*60000,
+			Content:     fmt.Sprintf("message %d about the weekend plans and dinner", i),
+			Type:        TypeGeneric,
+		}
+		if i%10 == 0 {
+			m.Reactions = &[]Reaction{{Reaction: "😍", Actor: senders[(i+1)%len(senders)]}}
+		}
+		blob.Messages = append(blob.Messages, m)
+	}
+
+	f, err := os.Create(filepath.Join(dir, "message_1.json"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(blob); err != nil {
+		b.Fatal(err)
+	}
+
+	return root
+}
+
+// peakHeap runs f while sampling the heap in use and returns how far the
+// heap grew above where it was before f started
+func peakHeap(f func()) uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	base, peak := stats.HeapInuse, stats.HeapInuse
+
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		var s runtime.MemStats
+		for {
+			runtime.ReadMemStats(&s)
+			if s.HeapInuse > peak {
+				peak = s.HeapInuse
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	f()
+	close(done)
+	<-sampled
+
+	return peak - base
+}
+
+// benchmarkPeakHeap runs f b.N times and reports the largest heap growth
+// of a single run, unlike the allocations this is what has to fit in memory
+func benchmarkPeakHeap(b *testing.B, f func() error) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		var err error
+		if p := peakHeap(func() { err = f() }); p > peak {
+			peak = p
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+}
+
+func BenchmarkParseExport(b *testing.B) {
+	root := writeLargeThread(b, benchMessages)
+	benchmarkPeakHeap(b, func() error {
+		blobs, err := ParseExport(root)
+		if err != nil {
+			return err
+		}
+		AnalyzeMessagesWithOptions(blobs[0], Options{})
+		return nil
+	})
+}
+
+func BenchmarkAnalyzeExportStream(b *testing.B) {
+	root := writeLargeThread(b, benchMessages)
+	benchmarkPeakHeap(b, func() error {
+		_, err := AnalyzeExportStream(Options{}, "", root)
+		return err
+	})
+}
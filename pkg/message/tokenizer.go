@@ -0,0 +1,193 @@
+package message
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Tokenizer splits message content into the words that are counted
+type Tokenizer interface {
+	Tokenize(s string) []string
+}
+
+// Normalizer rewrites text before it is split into words
+type Normalizer func(string) string
+
+// UnicodeTokenizer splits text into runs of letters, marks and numbers in
+// any script. Apostrophes inside a word are kept, as is a leading @ so
+// mentions can be told apart. Han, Hiragana and Katakana have no spaces
+// between words, with CJK set their runs become overlapping character
+// bigrams instead of one token per sentence.
+type UnicodeTokenizer struct {
+	Lowercase   bool
+	CJK         bool
+	Normalizers []Normalizer
+}
+
+// NewTokenizer returns the default tokenizer, which lowercases and applies
+// NFKC normalization
+func NewTokenizer() *UnicodeTokenizer {
+	return &UnicodeTokenizer{
+		Lowercase:   true,
+		Normalizers: []Normalizer{FoldCompatibility},
+	}
+}
+
+// Tokenize splits s into words
+func (t *UnicodeTokenizer) Tokenize(s string) []string {
+	for _, normalize := range t.Normalizers {
+		s = normalize(s)
+	}
+	if t.Lowercase {
+		s = strings.ToLower(s)
+	}
+
+	words := []string{}
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) && !(runes[i] == '@' && i+1 < len(runes) && isWordRune(runes[i+1])) {
+			i++
+			continue
+		}
+
+		start := i
+		if runes[i] == '@' {
+			i++
+		}
+		cjk := isCJK(runes[i])
+		for i < len(runes) {
+			r := runes[i]
+			if isWordRune(r) && isCJK(r) == cjk {
+				i++
+				continue
+			}
+			if isApostrophe(r) && i+1 < len(runes) && i > start && isWordRune(runes[i+1]) && !cjk {
+				i++
+				continue
+			}
+			break
+		}
+
+		if cjk && t.CJK {
+			words = append(words, cjkBigrams(runes[start:i])...)
+		} else {
+			words = append(words, string(runes[start:i]))
+		}
+	}
+
+	return words
+}
+
+// cjkBigrams splits a run of CJK characters into overlapping pairs
+func cjkBigrams(run []rune) []string {
+	if len(run) < 2 {
+		return []string{string(run)}
+	}
+
+	bigrams := []string{}
+	for i := 0; i+1 < len(run); i++ {
+		bigrams = append(bigrams, string(run[i:i+2]))
+	}
+
+	return bigrams
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r)
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '\u2019'
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// isShortWord reports whether the word is a single character that carries
+// no meaning by itself, single CJK characters are words of their own
+func isShortWord(word string) bool {
+	r, size := utf8.DecodeRuneInString(word)
+	return len(word) <= size && !isCJK(r)
+}
+
+// FoldCompatibility applies NFKC normalization, folding full width and half
+// width forms, ligatures, circled and superscript digits and the other
+// compatibility characters to their plain equivalents
+func FoldCompatibility(s string) string {
+	return norm.NFKC.String(s)
+}
+
+// accentFolds lists the accented forms of every Latin letter, covering
+// Western and Central European languages and Vietnamese
+var accentFolds = func() map[rune]string {
+	letters := map[string]string{
+		"a": "àáâãäåāăąǎǟǡǻȁȃȧạảấầẩẫậắằẳẵặ",
+		"A": "ÀÁÂÃÄÅĀĂĄǍǞǠǺȀȂȦẠẢẤẦẨẪẬẮẰẲẴẶ",
+		"c": "çćĉċč", "C": "ÇĆĈĊČ",
+		"d": "ďđ", "D": "ĎĐ",
+		"e": "èéêëēĕėęěȅȇȩẹẻẽếềểễệ",
+		"E": "ÈÉÊËĒĔĖĘĚȄȆȨẸẺẼẾỀỂỄỆ",
+		"g": "ĝğġģǧ", "G": "ĜĞĠĢǦ",
+		"h": "ĥħ", "H": "ĤĦ",
+		"i": "ìíîïĩīĭįıǐȉȋỉị", "I": "ÌÍÎÏĨĪĬĮİǏȈȊỈỊ",
+		"j": "ĵ", "J": "Ĵ",
+		"k": "ķǩ", "K": "ĶǨ",
+		"l": "ĺļľŀł", "L": "ĹĻĽĿŁ",
+		"n": "ñńņňǹ", "N": "ÑŃŅŇǸ",
+		"o": "òóôõöøōŏőơǒǫȍȏȯọỏốồổỗộớờởỡợ",
+		"O": "ÒÓÔÕÖØŌŎŐƠǑǪȌȎȮỌỎỐỒỔỖỘỚỜỞỠỢ",
+		"r": "ŕŗřȑȓ", "R": "ŔŖŘȐȒ",
+		"s": "śŝşšș", "S": "ŚŜŞŠȘ",
+		"t": "ţťŧț", "T": "ŢŤŦȚ",
+		"u": "ùúûüũūŭůűųưǔǖǘǚǜȕȗụủứừửữự",
+		"U": "ÙÚÛÜŨŪŬŮŰŲƯǓǕǗǙǛȔȖỤỦỨỪỬỮỰ",
+		"w": "ŵ", "W": "Ŵ",
+		"y": "ýÿŷỳỵỷỹ", "Y": "ÝŸŶỲỴỶỸ",
+		"z": "źżž", "Z": "ŹŻŽ",
+	}
+
+	folds := make(map[rune]string)
+	for base, accented := range letters {
+		for _, r := range accented {
+			folds[r] = base
+		}
+	}
+
+	return folds
+}()
+
+// FoldAccents strips accents from Latin letters, both precomposed letters
+// and letters followed by combining marks, so "café" and "cafe" are counted
+// as the same word. Marks on other scripts, e.g. Devanagari vowel signs,
+// are part of the word and kept.
+func FoldAccents(s string) string {
+	s = foldRunes(s, accentFolds)
+	latin := false
+	return strings.Map(func(r rune) rune {
+		if !unicode.Is(unicode.Mn, r) {
+			latin = unicode.Is(unicode.Latin, r)
+			return r
+		}
+		if latin {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func foldRunes(s string, folds map[rune]string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if fold, ok := folds[r]; ok {
+			sb.WriteString(fold)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/kevinwubert/fb-messenger-analysis/pkg/message"
 	"github.com/kevinwubert/fb-messenger-analysis/pkg/visualizer"
@@ -11,27 +15,220 @@ import (
 )
 
 func Main() error {
-	args := os.Args
-	if len(args) != 2 {
-		return errors.New("invalid number of arguments.\ncommand format: fb-messenger-analysis <message.json filepath>")
+	thread := flag.String("thread", "", "thread folder or title to analyze when the export has several threads")
+	stream := flag.Bool("stream", false, "stream each thread part instead of loading the whole thread, for very large threads")
+	display := flag.String("display", string(message.DisplayUnique), "how participants are named in charts: full, first or unique")
+	tz := flag.String("tz", "Local", "time zone activity is bucketed in, e.g. America/New_York")
+	responseCutoff := flag.Duration("response-cutoff", 6*time.Hour, "longest gap between messages still counted as a reply, 0 counts every gap")
+	sessionGap := flag.Duration("session-gap", time.Hour, "silence that ends a conversation")
+	ngrams := flag.String("ngrams", "2,3", "comma separated phrase lengths counted alongside single words")
+	cjk := flag.Bool("cjk", false, "split Chinese and Japanese text into character bigrams")
+	lowercase := flag.Bool("lowercase", true, "count words regardless of case, -lowercase=false keeps \"Hello\" and \"hello\" apart")
+	nfkc := flag.Bool("nfkc", true, "apply NFKC normalization so full width, half width and other compatibility characters count as their plain form")
+	foldAccents := flag.Bool("fold-accents", false, "count accented words as their unaccented form")
+	languages := flag.String("stopwords", "en", "comma separated languages whose built in stopwords are left out of word counts, "+
+		strings.Join(message.StopwordLanguages(), ", "))
+	stopwordFiles := &listFlag{}
+	flag.Var(stopwordFiles, "stopwords-file", "file of extra stopwords, one per line, may be repeated")
+	extraStopwords := &listFlag{}
+	flag.Var(extraStopwords, "stopword", "extra word to leave out of word counts, may be repeated")
+	keepWords := &listFlag{}
+	flag.Var(keepWords, "keep-word", "stopword to count anyway, may be repeated")
+	aliases := nameMapFlag{}
+	flag.Var(aliases, "alias", "merge a participant into another as \"Old Name=New Name\", may be repeated")
+	nicknames := nameMapFlag{}
+	flag.Var(nicknames, "nickname", "resolve @mentions of a nickname to a participant as \"Nickname=Full Name\", may be repeated")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		return errors.New("invalid number of arguments.\ncommand format: fb-messenger-analysis [flags] <export zip, export directory, thread directory or message.json>...")
 	}
 
-	messageFilepath := os.Args[1]
-	messageBlob, err := message.ParseMessages(messageFilepath)
+	displayStrategy, err := message.ParseDisplayStrategy(*display)
+	if err != nil {
+		return err
+	}
+	location, err := time.LoadLocation(*tz)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse messages")
+		return errors.Wrap(err, "invalid time zone")
+	}
+	opts := message.DefaultOptions()
+	opts.Aliases = aliases
+	opts.Nicknames = nicknames
+	opts.Display = displayStrategy
+	opts.Location = location
+	opts.ResponseCutoff = *responseCutoff
+	opts.SessionGap = *sessionGap
+	opts.MediaRoot = mediaRoot(flag.Args())
+	opts.NGramSizes, err = ngramSizes(*ngrams)
+	if err != nil {
+		return err
+	}
+
+	tokenizer := message.NewTokenizer()
+	tokenizer.CJK = *cjk
+	tokenizer.Lowercase = *lowercase
+	if !*nfkc {
+		tokenizer.Normalizers = []message.Normalizer{}
 	}
+	if *foldAccents {
+		tokenizer.Normalizers = append(tokenizer.Normalizers, message.FoldAccents)
+	}
+	opts.Tokenizer = tokenizer
+
+	opts.Stopwords, err = stopwords(*languages, *stopwordFiles, *extraStopwords, *keepWords)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("analyzing messages...")
-	analysis := message.AnalyzeMessages(messageBlob)
+	analysis, err := analyze(flag.Args(), *thread, *stream, opts)
+	if err != nil {
+		return err
+	}
 	sortedAnalysis := message.SortAnalysis(analysis)
 	fmt.Println("finished analyzing messages...")
 	fmt.Println("starting facebook messenger analysis server...")
 
 	visualizerClient := visualizer.New(sortedAnalysis)
 	http.HandleFunc("/graph", visualizerClient.DrawBarGraphHandler)
+	http.HandleFunc("/timeline", visualizerClient.DrawTimelineHandler)
+	http.HandleFunc("/heatmap", visualizerClient.DrawHeatmapHandler)
+	http.HandleFunc("/responseTimes", visualizerClient.DrawResponseTimesHandler)
 	http.HandleFunc("/topSticker", visualizerClient.TopStickerHandler)
 	http.HandleFunc("/getNames", visualizerClient.GetNamesHandler)
+	http.HandleFunc("/getTypes", visualizerClient.GetTypesHandler)
+	http.HandleFunc("/getStopwords", visualizerClient.GetStopwordsHandler)
+	http.HandleFunc("/reactions", visualizerClient.GetReactionsHandler)
+	http.HandleFunc("/interactions", visualizerClient.GetInteractionsHandler)
+	http.HandleFunc("/links", visualizerClient.GetLinksHandler)
+	http.HandleFunc("/media", visualizerClient.DrawMediaHandler)
+	http.HandleFunc("/calls", visualizerClient.DrawCallsHandler)
+	http.HandleFunc("/membership", visualizerClient.GetMembershipHandler)
+	http.HandleFunc("/metrics", visualizerClient.GetMetricsHandler)
+	http.HandleFunc("/interactionGraph", visualizerClient.DrawInteractionsHandler)
 
 	err = http.ListenAndServe(":80", nil)
 	return err
 }
+
+// nameMapFlag collects repeated "Some Name=Other Name" flags, such as
+// -alias and -nickname
+type nameMapFlag map[string]string
+
+func (f nameMapFlag) String() string {
+	pairs := []string{}
+	for alias, name := range f {
+		pairs = append(pairs, alias+"="+name)
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (f nameMapFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return errors.Errorf("%s must look like \"Some Name=Other Name\"", value)
+	}
+	f[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+
+	return nil
+}
+
+// ngramSizes parses a comma separated list of phrase lengths
+func ngramSizes(s string) ([]int, error) {
+	sizes := []int{}
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 2 {
+			return nil, errors.Errorf("invalid phrase length %q, phrases are at least 2 words", field)
+		}
+		sizes = append(sizes, n)
+	}
+
+	return sizes, nil
+}
+
+// listFlag collects the values of a repeated flag
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// stopwords builds the stopword list from the built in lists of the
+// languages and the stopword files, plus the extra words and minus the
+// words to keep
+func stopwords(languages string, files []string, extra []string, keep []string) ([]string, error) {
+	words := []string{}
+	for _, language := range strings.Split(languages, ",") {
+		if language = strings.TrimSpace(language); language == "" {
+			continue
+		}
+		builtin, err := message.BuiltinStopwords(language)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, builtin...)
+	}
+	for _, file := range files {
+		loaded, err := message.LoadStopwords(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load "+file)
+		}
+		words = append(words, loaded...)
+	}
+	words = append(words, extra...)
+
+	kept := []string{}
+	for _, word := range words {
+		keepWord := false
+		for _, k := range keep {
+			if strings.EqualFold(word, k) {
+				keepWord = true
+			}
+		}
+		if !keepWord {
+			kept = append(kept, word)
+		}
+	}
+
+	return kept, nil
+}
+
+// mediaRoot returns the first of the paths that is a folder, media files
+// can't be read from zip archives
+func mediaRoot(paths []string) string {
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path
+		}
+	}
+
+	return ""
+}
+
+func analyze(paths []string, thread string, stream bool, opts message.Options) (message.Analysis, error) {
+	if stream {
+		analysis, err := message.AnalyzeExportStream(opts, thread, paths...)
+		if err != nil {
+			return message.Analysis{}, errors.Wrap(err, "failed to analyze messages")
+		}
+		return analysis, nil
+	}
+
+	messageBlob, err := message.ParseExportThread(thread, paths...)
+	if err != nil {
+		return message.Analysis{}, errors.Wrap(err, "failed to parse messages")
+	}
+
+	return message.AnalyzeMessagesWithOptions(messageBlob, opts), nil
+}
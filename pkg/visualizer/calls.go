@@ -0,0 +1,119 @@
+package visualizer
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/kevinwubert/fb-messenger-analysis/pkg/message"
+	"github.com/pkg/errors"
+
+	"github.com/wcharczuk/go-chart"
+)
+
+// callStats are the values of the stat query of DrawCallsHandler
+var callStats = map[string]string{
+	"count":   "Calls started",
+	"missed":  "Missed calls",
+	"minutes": "Minutes on calls",
+	"average": "Average call minutes",
+	"rate":    "Share of calls missed",
+}
+
+// DrawCallsHandler draws a bar per participant for the stat query: count
+// (the default), missed, minutes, average or rate, the share of calls that
+// were missed. With the bucket query it draws the calls the name query,
+// which defaults to everyone, started in every day, week, month or year.
+func (c client) DrawCallsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if bucket := query.Get("bucket"); bucket != "" {
+		if _, ok := bucketFormats[bucket]; !ok {
+			fmt.Printf("invalid bucket")
+			WriteErrorResponse(w, errors.New("invalid bucket, expected day, week, month or year"))
+			return
+		}
+		name := query.Get("name")
+		if name == "" {
+			name = "everyone"
+		}
+		name, err := c.resolveName(name)
+		if err != nil {
+			fmt.Printf("invalid name")
+			WriteErrorResponse(w, err)
+			return
+		}
+
+		DrawBarChart(w, "Calls per "+bucket+" started by "+c.SortedAnalysis.DisplayName(name), c.callsOverTime(name, bucket))
+		return
+	}
+
+	stat := query.Get("stat")
+	if stat == "" {
+		stat = "count"
+	}
+	title, ok := callStats[stat]
+	if !ok {
+		fmt.Printf("invalid stat")
+		WriteErrorResponse(w, errors.New("invalid stat, expected count, missed, minutes, average or rate"))
+		return
+	}
+
+	DrawBarChart(w, title, c.callValues(stat))
+}
+
+// callValues returns the stat of every participant who started a call,
+// highest first
+func (c client) callValues(stat string) []chart.Value {
+	values := []chart.Value{}
+	for _, name := range c.SortedAnalysis.Participants {
+		pa, ok := c.SortedAnalysis.SortedParticipantAnalyses[name]
+		if !ok || pa.Calls.Count == 0 {
+			continue
+		}
+		values = append(values, chart.Value{Value: callStat(pa.Calls, stat), Label: c.SortedAnalysis.DisplayName(name)})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Value > values[j].Value
+	})
+
+	return values
+}
+
+func callStat(calls message.SortedCalls, stat string) float64 {
+	switch stat {
+	case "missed":
+		return float64(calls.Missed)
+	case "minutes":
+		return float64(calls.TotalSeconds) / 60
+	case "average":
+		return calls.AverageSeconds / 60
+	case "rate":
+		return calls.MissedRate
+	}
+
+	return float64(calls.Count)
+}
+
+// callsOverTime returns the calls started in every bucket of the chat
+func (c client) callsOverTime(name string, bucket string) []chart.Value {
+	calls := c.SortedAnalysis.Calls
+	if name != "everyone" {
+		calls = c.SortedAnalysis.SortedParticipantAnalyses[name].Calls
+	}
+
+	counts := make(map[string]int)
+	for _, v := range calls.Activity.Bucket(bucket) {
+		counts[v.Value] = v.Freq
+	}
+
+	values := []chart.Value{}
+	for _, t := range c.bucketRange(bucket) {
+		values = append(values, chart.Value{
+			Value: float64(counts[message.BucketKey(t, bucket)]),
+			Label: t.Format(bucketFormats[bucket]),
+		})
+	}
+
+	return values
+}
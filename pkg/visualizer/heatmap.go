@@ -0,0 +1,144 @@
+package visualizer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+)
+
+const (
+	heatmapCellSize   = 44
+	heatmapLeftMargin = 110
+	heatmapTopMargin  = 80
+	heatmapPadding    = 20
+)
+
+// heatmapWeekdays lists the rows of the heatmap Monday first, as indexes
+// into the time.Weekday ordered activity
+var heatmapWeekdays = []struct {
+	Label   string
+	Weekday int
+}{
+	{"Monday", 1}, {"Tuesday", 2}, {"Wednesday", 3}, {"Thursday", 4},
+	{"Friday", 5}, {"Saturday", 6}, {"Sunday", 0},
+}
+
+// DrawHeatmapHandler draws a punch card of the messages sent in every
+// hour of every weekday for the name query, which defaults to everyone
+func (c client) DrawHeatmapHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = "everyone"
+	}
+
+	name, err := c.resolveName(name)
+	if err != nil {
+		fmt.Printf("invalid name")
+		WriteErrorResponse(w, err)
+		return
+	}
+
+	counts := c.SortedAnalysis.Activity.HourByWeekday
+	if name != "everyone" {
+		counts = c.SortedAnalysis.SortedParticipantAnalyses[name].Activity.HourByWeekday
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	err = DrawHeatmap(w, "Messages by hour and weekday for "+c.SortedAnalysis.DisplayName(name), counts)
+
+	if err != nil {
+		fmt.Printf("Error rendering heatmap: %v\n", err)
+	}
+}
+
+// DrawHeatmap renders the hour by weekday counts, indexed by time.Weekday,
+// as a PNG with darker cells for quieter hours
+func DrawHeatmap(w io.Writer, title string, counts [7][24]int) error {
+	width := heatmapLeftMargin + 24*heatmapCellSize + heatmapPadding
+	height := heatmapTopMargin + 7*heatmapCellSize + heatmapPadding
+
+	r, err := chart.PNG(width, height)
+	if err != nil {
+		return errors.Wrap(err, "failed to create renderer")
+	}
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return errors.Wrap(err, "failed to load font")
+	}
+	r.SetFont(font)
+
+	fillRect(r, 0, 0, width, height, chart.ColorWhite)
+
+	max := 0
+	for _, row := range counts {
+		for _, count := range row {
+			if count > max {
+				max = count
+			}
+		}
+	}
+
+	r.SetFontColor(chart.ColorBlack)
+	r.SetFontSize(16)
+	titleBox := r.MeasureText(title)
+	r.Text(title, (width-titleBox.Width())/2, heatmapPadding+titleBox.Height())
+
+	r.SetFontSize(10)
+	for hour := 0; hour < 24; hour++ {
+		label := strconv.Itoa(hour)
+		box := r.MeasureText(label)
+		x := heatmapLeftMargin + hour*heatmapCellSize + (heatmapCellSize-box.Width())/2
+		r.Text(label, x, heatmapTopMargin-8)
+	}
+
+	for row, day := range heatmapWeekdays {
+		y := heatmapTopMargin + row*heatmapCellSize
+		r.SetFontColor(chart.ColorBlack)
+		r.SetFontSize(10)
+		box := r.MeasureText(day.Label)
+		r.Text(day.Label, heatmapLeftMargin-box.Width()-10, y+(heatmapCellSize+box.Height())/2)
+
+		for hour := 0; hour < 24; hour++ {
+			x := heatmapLeftMargin + hour*heatmapCellSize
+			count := counts[day.Weekday][hour]
+			fillRect(r, x+1, y+1, heatmapCellSize-2, heatmapCellSize-2, heatmapColor(count, max))
+
+			if count == 0 {
+				continue
+			}
+			label := strconv.Itoa(count)
+			r.SetFontSize(9)
+			r.SetFontColor(chart.ColorWhite)
+			box := r.MeasureText(label)
+			r.Text(label, x+(heatmapCellSize-box.Width())/2, y+(heatmapCellSize+box.Height())/2)
+		}
+	}
+
+	return r.Save(w)
+}
+
+func heatmapColor(count int, max int) drawing.Color {
+	if count == 0 || max == 0 {
+		return drawing.ColorFromHex("eeeeee")
+	}
+
+	return chart.Viridis(float64(count), 0, float64(max))
+}
+
+func fillRect(r chart.Renderer, x, y, width, height int, color drawing.Color) {
+	r.SetFillColor(color)
+	r.SetStrokeColor(color)
+	r.MoveTo(x, y)
+	r.LineTo(x+width, y)
+	r.LineTo(x+width, y+height)
+	r.LineTo(x, y+height)
+	r.Close()
+	r.Fill()
+}
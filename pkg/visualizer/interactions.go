@@ -0,0 +1,245 @@
+package visualizer
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/kevinwubert/fb-messenger-analysis/pkg/message"
+	"github.com/pkg/errors"
+
+	"github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+)
+
+const (
+	interactionsSize      = 900
+	interactionsMargin    = 150
+	interactionsMinRadius = 10
+	interactionsMaxRadius = 36
+)
+
+// GetInteractionsHandler returns the interaction graph as JSON with
+// participants under their display names
+func (c client) GetInteractionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	WriteJSONResponse(w, c.displayInteractions(c.SortedAnalysis.Interactions))
+}
+
+// DrawInteractionsHandler draws the interaction graph as a network diagram.
+// Query parameters: kind (replies, mentions, reactions or all, the default)
+// picks what the edges are weighted by and format (png or svg, defaults to
+// png).
+func (c client) DrawInteractionsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	kind := query.Get("kind")
+	if kind == "" {
+		kind = "all"
+	}
+	if _, ok := edgeWeight(message.InteractionEdge{}, kind); !ok {
+		fmt.Printf("invalid kind")
+		WriteErrorResponse(w, errors.New("invalid kind, expected replies, mentions, reactions or all"))
+		return
+	}
+
+	format := query.Get("format")
+	provider := chart.PNG
+	contentType := "image/png"
+	switch format {
+	case "", "png":
+	case "svg":
+		provider = chart.SVG
+		contentType = "image/svg+xml"
+	default:
+		fmt.Printf("invalid format")
+		WriteErrorResponse(w, errors.New("invalid format, expected png or svg"))
+		return
+	}
+
+	title := "Who interacts with whom"
+	if kind != "all" {
+		title = "Who " + map[string]string{"replies": "replies to", "mentions": "mentions", "reactions": "reacts to"}[kind] + " whom"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	err := DrawInteractions(w, provider, title, c.SortedAnalysis.Interactions, kind, c.SortedAnalysis.DisplayNames)
+
+	if err != nil {
+		fmt.Printf("Error rendering interactions: %v\n", err)
+	}
+}
+
+// displayInteractions renames the graph's participants to their display names
+func (c client) displayInteractions(g message.InteractionGraph) message.InteractionGraph {
+	display := message.InteractionGraph{
+		Nodes: []message.InteractionNode{},
+		Edges: []message.InteractionEdge{},
+	}
+	for _, n := range g.Nodes {
+		n.Name = c.SortedAnalysis.DisplayName(n.Name)
+		display.Nodes = append(display.Nodes, n)
+	}
+	for _, e := range g.Edges {
+		e.From = c.SortedAnalysis.DisplayName(e.From)
+		e.To = c.SortedAnalysis.DisplayName(e.To)
+		display.Edges = append(display.Edges, e)
+	}
+
+	return display
+}
+
+// edgeWeight returns the weight of the edge for the kind of interaction
+func edgeWeight(e message.InteractionEdge, kind string) (int, bool) {
+	switch kind {
+	case "replies":
+		return e.Replies, true
+	case "mentions":
+		return e.Mentions, true
+	case "reactions":
+		return e.Reactions, true
+	case "all":
+		return e.Weight, true
+	}
+
+	return 0, false
+}
+
+// DrawInteractions renders the graph with the participants around a circle,
+// sized by the messages they sent, and an arrow for each edge that is
+// thicker the more the kind of interaction happened. Nodes are laid out by
+// name and labeled by their entry in labels, if any, so participants that
+// share a label still get a node each.
+func DrawInteractions(w io.Writer, provider chart.RendererProvider, title string, g message.InteractionGraph, kind string, labels map[string]string) error {
+	width := interactionsSize + 2*interactionsMargin
+	height := width
+
+	r, err := provider(width, height)
+	if err != nil {
+		return errors.Wrap(err, "failed to create renderer")
+	}
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return errors.Wrap(err, "failed to load font")
+	}
+	r.SetFont(font)
+
+	fillRect(r, 0, 0, width, height, chart.ColorWhite)
+
+	r.SetFontColor(chart.ColorBlack)
+	r.SetFontSize(16)
+	titleBox := r.MeasureText(title)
+	r.Text(title, (width-titleBox.Width())/2, heatmapPadding+titleBox.Height())
+
+	maxMessages := 1
+	for _, n := range g.Nodes {
+		if n.Messages > maxMessages {
+			maxMessages = n.Messages
+		}
+	}
+	maxWeight := 1
+	for _, e := range g.Edges {
+		if weight, _ := edgeWeight(e, kind); weight > maxWeight {
+			maxWeight = weight
+		}
+	}
+
+	center := float64(width) / 2
+	ring := float64(interactionsSize) / 2
+	positions := make(map[string][2]float64)
+	radii := make(map[string]float64)
+	for i, n := range g.Nodes {
+		angle := 2*math.Pi*float64(i)/float64(len(g.Nodes)) - math.Pi/2
+		positions[n.Name] = [2]float64{center + ring*math.Cos(angle), center + ring*math.Sin(angle)}
+		radii[n.Name] = interactionsMinRadius + (interactionsMaxRadius-interactionsMinRadius)*math.Sqrt(float64(n.Messages)/float64(maxMessages))
+	}
+
+	for _, e := range g.Edges {
+		weight, _ := edgeWeight(e, kind)
+		from, okFrom := positions[e.From]
+		to, okTo := positions[e.To]
+		if weight == 0 || !okFrom || !okTo {
+			continue
+		}
+		color := chart.Viridis(float64(weight), 0, float64(maxWeight)).WithAlpha(200)
+		drawArrow(r, from, to, radii[e.From], radii[e.To], 1+7*float64(weight)/float64(maxWeight), color)
+	}
+
+	r.SetStrokeWidth(1)
+	for i, n := range g.Nodes {
+		p := positions[n.Name]
+		fillCircle(r, p[0], p[1], radii[n.Name], chart.GetDefaultColor(i))
+
+		r.SetFontColor(chart.ColorBlack)
+		r.SetFontSize(12)
+		label := n.Name
+		if l, ok := labels[n.Name]; ok {
+			label = l
+		}
+		box := r.MeasureText(label)
+		dx, dy := p[0]-center, p[1]-center
+		length := math.Hypot(dx, dy)
+		offset := radii[n.Name] + 14
+		x := p[0] + dx/length*offset
+		y := p[1] + dy/length*offset
+		if dx < -1 {
+			x -= float64(box.Width())
+		} else if math.Abs(dx) <= 1 {
+			x -= float64(box.Width()) / 2
+		}
+		r.Text(label, int(x), int(y)+box.Height()/2)
+	}
+
+	return r.Save(w)
+}
+
+// drawArrow draws an arrow between the edges of two nodes, shifted to the
+// right of its direction so edges both ways stay apart
+func drawArrow(r chart.Renderer, from, to [2]float64, fromRadius, toRadius float64, width float64, color drawing.Color) {
+	dx, dy := to[0]-from[0], to[1]-from[1]
+	length := math.Hypot(dx, dy)
+	if length <= fromRadius+toRadius {
+		return
+	}
+	ux, uy := dx/length, dy/length
+	px, py := -uy*6, ux*6
+
+	x1, y1 := from[0]+ux*fromRadius+px, from[1]+uy*fromRadius+py
+	x2, y2 := to[0]-ux*toRadius+px, to[1]-uy*toRadius+py
+	head := 8 + 2*width
+	bx, by := x2-ux*head, y2-uy*head
+
+	r.SetFillColor(drawing.ColorTransparent)
+	r.SetStrokeColor(color)
+	r.SetStrokeWidth(width)
+	r.MoveTo(int(x1), int(y1))
+	r.LineTo(int(bx), int(by))
+	r.Stroke()
+
+	r.SetStrokeWidth(1)
+	r.SetFillColor(color)
+	r.MoveTo(int(x2), int(y2))
+	r.LineTo(int(bx-uy*head/2), int(by+ux*head/2))
+	r.LineTo(int(bx+uy*head/2), int(by-ux*head/2))
+	r.Close()
+	r.FillStroke()
+}
+
+// fillCircle draws a filled circle as a polygon, which renders the same
+// with the PNG and the SVG renderer
+func fillCircle(r chart.Renderer, x, y, radius float64, color drawing.Color) {
+	r.SetFillColor(color)
+	r.SetStrokeColor(chart.ColorWhite)
+	for i := 0; i <= 48; i++ {
+		angle := 2 * math.Pi * float64(i) / 48
+		px, py := int(x+radius*math.Cos(angle)), int(y+radius*math.Sin(angle))
+		if i == 0 {
+			r.MoveTo(px, py)
+		} else {
+			r.LineTo(px, py)
+		}
+	}
+	r.Close()
+	r.FillStroke()
+}
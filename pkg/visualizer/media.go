@@ -0,0 +1,105 @@
+package visualizer
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kevinwubert/fb-messenger-analysis/pkg/message"
+	"github.com/pkg/errors"
+
+	"github.com/wcharczuk/go-chart"
+)
+
+// DrawMediaHandler draws how much of each kind of media the name query,
+// which defaults to everyone, sent. With the kind query it draws a bar per
+// participant for that kind of media instead.
+func (c client) DrawMediaHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := query.Get("name")
+	if name == "" {
+		name = "everyone"
+	}
+
+	name, err := c.resolveName(name)
+	if err != nil {
+		fmt.Printf("invalid name")
+		WriteErrorResponse(w, err)
+		return
+	}
+
+	if kind := query.Get("kind"); kind != "" {
+		if !isMediaKind(kind) {
+			fmt.Printf("invalid kind")
+			WriteErrorResponse(w, errors.New("invalid kind, expected photos, videos, audio, gifs, files or shares"))
+			return
+		}
+		DrawBarChart(w, "Participants by "+kind+" sent", c.mediaByParticipant(kind))
+		return
+	}
+
+	media := c.SortedAnalysis.Media
+	audio := c.SortedAnalysis.AudioDuration
+	if name != "everyone" {
+		media = c.SortedAnalysis.SortedParticipantAnalyses[name].Media
+		audio = c.SortedAnalysis.SortedParticipantAnalyses[name].AudioDuration
+	}
+
+	DrawBarChart(w, GetMediaTitle(c.SortedAnalysis.DisplayName(name), audio), mediaValues(media))
+}
+
+// GetMediaTitle gets the media breakdown title
+func GetMediaTitle(name string, audio time.Duration) string {
+	title := "Media sent by " + name
+	if audio > 0 {
+		title += " (" + audio.Round(time.Second).String() + " of voice messages)"
+	}
+
+	return title
+}
+
+// mediaValues returns a bar for every kind of media, in the order of
+// message.MediaKinds
+func mediaValues(media message.StringFreqs) []chart.Value {
+	counts := make(map[string]int)
+	for _, v := range media {
+		counts[v.Value] = v.Freq
+	}
+
+	values := []chart.Value{}
+	for _, kind := range message.MediaKinds {
+		values = append(values, chart.Value{Value: float64(counts[kind]), Label: kind})
+	}
+
+	return values
+}
+
+// mediaByParticipant returns a bar for every participant who sent the kind
+// of media, most first
+func (c client) mediaByParticipant(kind string) []chart.Value {
+	counts := make(map[string]int)
+	for name, pa := range c.SortedAnalysis.SortedParticipantAnalyses {
+		for _, v := range pa.Media {
+			if v.Value == kind {
+				counts[name] = v.Freq
+			}
+		}
+	}
+
+	values := []chart.Value{}
+	for _, v := range message.MapToSortedStringFreqs(counts) {
+		values = append(values, chart.Value{Value: float64(v.Freq), Label: c.SortedAnalysis.DisplayName(v.Value)})
+	}
+
+	return values
+}
+
+func isMediaKind(kind string) bool {
+	for _, k := range message.MediaKinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package visualizer
+
+import (
+	"net/http"
+
+	"github.com/kevinwubert/fb-messenger-analysis/pkg/message"
+)
+
+// membershipResponse is the body of GetMembershipHandler. Names are the
+// participants' full names, display names can be shared so DisplayNames
+// maps each name to the one charts use.
+type membershipResponse struct {
+	Events       []message.MembershipEvent   `json:"events"`
+	Tenures      map[string][]message.Tenure `json:"tenures"`
+	DaysInChat   map[string]float64          `json:"daysInChat"`
+	DisplayNames map[string]string           `json:"displayNames"`
+}
+
+// GetMembershipHandler returns the membership timeline as JSON: the joins,
+// leaves, renames and photo changes in order, and when and for how many
+// days each participant was in the chat
+func (c client) GetMembershipHandler(w http.ResponseWriter, r *http.Request) {
+	response := membershipResponse{
+		Events:       []message.MembershipEvent{},
+		Tenures:      make(map[string][]message.Tenure),
+		DaysInChat:   make(map[string]float64),
+		DisplayNames: make(map[string]string),
+	}
+
+	for _, e := range c.SortedAnalysis.Membership.Events {
+		if e.Name != "" {
+			response.DisplayNames[e.Name] = c.SortedAnalysis.DisplayName(e.Name)
+		}
+		response.DisplayNames[e.By] = c.SortedAnalysis.DisplayName(e.By)
+		response.Events = append(response.Events, e)
+	}
+	for name, tenures := range c.SortedAnalysis.Membership.Tenures {
+		response.Tenures[name] = tenures
+		response.DaysInChat[name] = message.DaysInChat(tenures)
+		response.DisplayNames[name] = c.SortedAnalysis.DisplayName(name)
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	WriteJSONResponse(w, response)
+}
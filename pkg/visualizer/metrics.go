@@ -0,0 +1,29 @@
+package visualizer
+
+import (
+	"net/http"
+
+	"github.com/kevinwubert/fb-messenger-analysis/pkg/message"
+)
+
+// participantMetrics is a participant's entry in GetMetricsHandler
+type participantMetrics struct {
+	DisplayName string `json:"displayName"`
+	message.Metrics
+}
+
+// GetMetricsHandler returns the normalized metrics of every participant as
+// JSON keyed by participant, display names can be shared so each entry
+// carries its own
+func (c client) GetMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	metrics := make(map[string]participantMetrics)
+	for name, pa := range c.SortedAnalysis.SortedParticipantAnalyses {
+		metrics[name] = participantMetrics{
+			DisplayName: c.SortedAnalysis.DisplayName(name),
+			Metrics:     pa.Metrics,
+		}
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	WriteJSONResponse(w, metrics)
+}
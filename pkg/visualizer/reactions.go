@@ -0,0 +1,113 @@
+package visualizer
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/kevinwubert/fb-messenger-analysis/pkg/message"
+	"github.com/pkg/errors"
+
+	"github.com/wcharczuk/go-chart"
+)
+
+// reactionsResponse is the body of GetReactionsHandler. Names are the
+// participants' full names, display names can be shared so DisplayNames
+// maps each name to the one charts use.
+type reactionsResponse struct {
+	Received     message.StringFreqs       `json:"received"`
+	From         message.StringFreqs       `json:"from"`
+	Matrix       map[string]map[string]int `json:"matrix"`
+	MostReacted  []message.ReactedMessage  `json:"mostReacted"`
+	DisplayNames map[string]string         `json:"displayNames"`
+}
+
+// GetReactionsHandler returns the reactions received as JSON. For everyone
+// received counts the reactions of each participant's messages, for a name
+// it counts their messages' reactions by emoji and from counts who reacted.
+// matrix maps each reactor to the authors they reacted to and mostReacted
+// lists the count most reacted messages, 10 by default.
+func (c client) GetReactionsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := query.Get("name")
+	if name == "" {
+		name = "everyone"
+	}
+	count := 10
+	if query.Get("count") != "" {
+		var err error
+		count, err = strconv.Atoi(query.Get("count"))
+		if err != nil {
+			fmt.Printf("failed to parse count: %v\n", err)
+			WriteErrorResponse(w, errors.Wrap(err, "failed to parse count"))
+			return
+		}
+	}
+
+	name, err := c.resolveName(name)
+	if err != nil {
+		fmt.Printf("invalid name")
+		WriteErrorResponse(w, err)
+		return
+	}
+
+	response := reactionsResponse{
+		Received: c.receivedByAuthor(),
+		From:     message.StringFreqs{},
+		Matrix:   make(map[string]map[string]int),
+	}
+	response.DisplayNames = c.SortedAnalysis.DisplayNames
+	mostReacted := c.SortedAnalysis.MostReacted
+	if name != "everyone" {
+		pa := c.SortedAnalysis.SortedParticipantAnalyses[name]
+		response.Received = pa.ReactionsReceived
+		response.From = pa.ReactionsFrom
+		mostReacted = pa.MostReacted
+	}
+
+	response.Matrix = c.SortedAnalysis.ReactionMatrix
+
+	response.MostReacted = []message.ReactedMessage{}
+	for i, m := range mostReacted {
+		if i >= count {
+			break
+		}
+		response.MostReacted = append(response.MostReacted, m)
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	WriteJSONResponse(w, response)
+}
+
+// receivedByAuthor counts the reactions each participant's messages
+// received by full name, most first
+func (c client) receivedByAuthor() message.StringFreqs {
+	received := message.StringFreqs{}
+	for name, pa := range c.SortedAnalysis.SortedParticipantAnalyses {
+		if n := pa.ReactionsReceivedCount(); n > 0 {
+			received = append(received, message.StringFreq{Value: name, Freq: n})
+		}
+	}
+	sort.Stable(received)
+
+	return received
+}
+
+// receivedValues gets the values of the received graph type
+func (c client) receivedValues(name string) []chart.Value {
+	if name != "everyone" {
+		values := []chart.Value{}
+		for _, v := range c.SortedAnalysis.SortedParticipantAnalyses[name].ReactionsReceived {
+			values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
+		}
+		return values
+	}
+
+	values := []chart.Value{}
+	for _, v := range c.receivedByAuthor() {
+		values = append(values, chart.Value{Value: float64(v.Freq), Label: c.SortedAnalysis.DisplayName(v.Value)})
+	}
+
+	return values
+}
@@ -0,0 +1,82 @@
+package visualizer
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/kevinwubert/fb-messenger-analysis/pkg/message"
+	"github.com/pkg/errors"
+
+	"github.com/wcharczuk/go-chart"
+)
+
+// DrawResponseTimesHandler draws reply latency in minutes. For everyone
+// there is a bar per participant, for a name there is a bar per person
+// they answer. The stat query picks mean, p25, median, p75 or p90 and
+// defaults to median.
+func (c client) DrawResponseTimesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := query.Get("name")
+	if name == "" {
+		name = "everyone"
+	}
+	stat := query.Get("stat")
+	if stat == "" {
+		stat = "median"
+	}
+	if _, ok := (message.ResponseStats{}).Stat(stat); !ok {
+		fmt.Printf("invalid stat")
+		WriteErrorResponse(w, errors.New("invalid stat, expected mean, p25, median, p75 or p90"))
+		return
+	}
+
+	name, err := c.resolveName(name)
+	if err != nil {
+		fmt.Printf("invalid name")
+		WriteErrorResponse(w, err)
+		return
+	}
+
+	stats := make(map[string]message.ResponseStats)
+	if name == "everyone" {
+		for k, v := range c.SortedAnalysis.SortedParticipantAnalyses {
+			stats[k] = v.ResponseTimes
+		}
+	} else {
+		stats = c.SortedAnalysis.SortedParticipantAnalyses[name].ResponseTimesTo
+	}
+
+	DrawBarChart(w, GetResponseTimesTitle(c.SortedAnalysis.DisplayName(name), stat), c.responseTimeValues(stats, stat))
+}
+
+// GetResponseTimesTitle gets the response times graph title
+func GetResponseTimesTitle(name string, stat string) string {
+	if name == "everyone" {
+		return "Reply time in minutes (" + stat + ") per participant"
+	}
+
+	return "Reply time in minutes (" + stat + ") of " + name + " per person answered"
+}
+
+// responseTimeValues returns a bar of the stat in minutes for every entry
+// with replies, fastest first
+func (c client) responseTimeValues(stats map[string]message.ResponseStats, stat string) []chart.Value {
+	values := []chart.Value{}
+	for name, s := range stats {
+		if s.Count == 0 {
+			continue
+		}
+		seconds, _ := s.Stat(stat)
+		values = append(values, chart.Value{
+			Value: seconds / 60,
+			Label: c.SortedAnalysis.DisplayName(name),
+		})
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Value < values[j].Value
+	})
+
+	return values
+}
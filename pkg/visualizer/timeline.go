@@ -0,0 +1,229 @@
+package visualizer
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinwubert/fb-messenger-analysis/pkg/message"
+	"github.com/pkg/errors"
+
+	"github.com/wcharczuk/go-chart"
+)
+
+var bucketFormats = map[string]string{
+	message.BucketDay:   "2006-01-02",
+	message.BucketWeek:  "2006-01-02",
+	message.BucketMonth: "Jan 2006",
+	message.BucketYear:  "2006",
+}
+
+// DrawTimelineHandler draws the messages sent per bucket over time as a
+// line for each name. Query parameters: name (repeated or comma separated,
+// defaults to everyone), bucket (day, week, month or year, defaults to
+// month), smooth (moving average period), smoothing (sma or ema) and media
+// (a kind of media to draw instead of messages).
+func (c client) DrawTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	names := []string{}
+	for _, n := range query["name"] {
+		for _, name := range strings.Split(n, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = []string{"everyone"}
+	}
+
+	bucket := query.Get("bucket")
+	if bucket == "" {
+		bucket = message.BucketMonth
+	}
+	if _, ok := bucketFormats[bucket]; !ok {
+		fmt.Printf("invalid bucket")
+		WriteErrorResponse(w, errors.New("invalid bucket, expected day, week, month or year"))
+		return
+	}
+
+	period := 0
+	if smooth := query.Get("smooth"); smooth != "" {
+		var err error
+		period, err = strconv.Atoi(smooth)
+		if err != nil || period < 0 {
+			fmt.Printf("failed to parse smooth: %v\n", err)
+			WriteErrorResponse(w, errors.New("smooth must be a positive number"))
+			return
+		}
+	}
+	smoothing := query.Get("smoothing")
+	if smoothing == "" {
+		smoothing = "sma"
+	}
+	if smoothing != "sma" && smoothing != "ema" {
+		fmt.Printf("invalid smoothing")
+		WriteErrorResponse(w, errors.New("invalid smoothing, expected sma or ema"))
+		return
+	}
+
+	media := query.Get("media")
+	if media != "" && !isMediaKind(media) {
+		fmt.Printf("invalid media")
+		WriteErrorResponse(w, errors.New("invalid media, expected photos, videos, audio, gifs, files or shares"))
+		return
+	}
+
+	xValues := c.bucketRange(bucket)
+	if len(xValues) < 2 {
+		fmt.Printf("not enough activity")
+		WriteErrorResponse(w, errors.New("not enough activity to draw a timeline"))
+		return
+	}
+
+	// an all zero series has no y range, go-chart fails on it after the
+	// headers are written so the range always reaches at least 1
+	max := 1.0
+	series := []chart.Series{}
+	for _, name := range names {
+		resolved, err := c.resolveName(name)
+		if err != nil {
+			fmt.Printf("invalid name")
+			WriteErrorResponse(w, err)
+			return
+		}
+
+		ts := chart.TimeSeries{
+			Name:    c.SortedAnalysis.DisplayName(resolved),
+			XValues: xValues,
+			YValues: c.bucketValues(resolved, media, bucket, xValues),
+		}
+		for _, v := range ts.YValues {
+			if v > max {
+				max = v
+			}
+		}
+		series = append(series, smoothSeries(ts, smoothing, period))
+	}
+
+	graph := chart.Chart{
+		Title:      GetTimelineTitle(media, bucket, smoothing, period),
+		TitleStyle: chart.StyleShow(),
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top: 40,
+			},
+		},
+		Height: 512,
+		Width:  2048,
+		XAxis: chart.XAxis{
+			Style:          chart.StyleShow(),
+			ValueFormatter: chart.TimeValueFormatterWithFormat(bucketFormats[bucket]),
+		},
+		YAxis: chart.YAxis{
+			Style: chart.StyleShow(),
+			Range: &chart.ContinuousRange{
+				Min: 0,
+				Max: max,
+			},
+		},
+		Series: series,
+	}
+	graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	err := graph.Render(chart.PNG, w)
+
+	if err != nil {
+		fmt.Printf("Error rendering timeline: %v\n", err)
+	}
+}
+
+// GetTimelineTitle gets the timeline title
+func GetTimelineTitle(media string, bucket string, smoothing string, period int) string {
+	title := "Messages per " + bucket
+	if media != "" {
+		title = strings.Title(media) + " per " + bucket
+	}
+	if period > 1 {
+		title += " (" + strings.ToUpper(smoothing) + " " + strconv.Itoa(period) + ")"
+	}
+
+	return title
+}
+
+// smoothSeries wraps the series in a moving average when period is over one
+func smoothSeries(ts chart.TimeSeries, smoothing string, period int) chart.Series {
+	if period <= 1 {
+		return ts
+	}
+	if smoothing == "ema" {
+		return &chart.EMASeries{
+			Name:        ts.Name,
+			Period:      period,
+			InnerSeries: ts,
+		}
+	}
+
+	return chart.SMASeries{
+		Name:        ts.Name,
+		Period:      period,
+		InnerSeries: ts,
+	}
+}
+
+// bucketRange returns the start of every bucket from the first message of
+// the chat to the last, including buckets without any messages
+func (c client) bucketRange(bucket string) []time.Time {
+	counts := c.SortedAnalysis.Activity.Bucket(bucket)
+	if len(counts) == 0 {
+		return []time.Time{}
+	}
+
+	first, err := message.ParseBucketKey(counts[0].Value, bucket, time.UTC)
+	if err != nil {
+		return []time.Time{}
+	}
+	last, err := message.ParseBucketKey(counts[len(counts)-1].Value, bucket, time.UTC)
+	if err != nil {
+		return []time.Time{}
+	}
+
+	times := []time.Time{}
+	for t := first; !t.After(last); t = message.NextBucket(t, bucket) {
+		times = append(times, t)
+	}
+
+	return times
+}
+
+// bucketValues returns the message count, or the count of the kind of
+// media when media is set, of the name for every bucket
+func (c client) bucketValues(name string, media string, bucket string, xValues []time.Time) []float64 {
+	activity := c.SortedAnalysis.Activity
+	if media != "" {
+		activity = c.SortedAnalysis.MediaActivity[media]
+	}
+	if name != "everyone" {
+		activity = c.SortedAnalysis.SortedParticipantAnalyses[name].Activity
+		if media != "" {
+			activity = c.SortedAnalysis.SortedParticipantAnalyses[name].MediaActivity[media]
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, v := range activity.Bucket(bucket) {
+		counts[v.Value] = v.Freq
+	}
+
+	values := []float64{}
+	for _, t := range xValues {
+		values = append(values, float64(counts[message.BucketKey(t, bucket)]))
+	}
+
+	return values
+}
@@ -12,6 +12,14 @@ import (
 	"github.com/wcharczuk/go-chart"
 )
 
+// GraphTypes are the values accepted by the type query of DrawBarGraphHandler
+var GraphTypes = []string{"words", "stickers", "mentions", "reactions", "emojis",
+	"phrases", "bigrams", "trigrams", "starters", "enders", "received",
+	"mentioned", "unresolved", "links", "distinctive"}
+
+// fixedNGramTypes are the graph types that name a single phrase length
+var fixedNGramTypes = map[string]int{"bigrams": 2, "trigrams": 3}
+
 type client struct {
 	SortedAnalysis message.SortedAnalysis
 }
@@ -19,7 +27,20 @@ type client struct {
 // Client returns a client for the visualizer
 type Client interface {
 	DrawBarGraphHandler(w http.ResponseWriter, r *http.Request)
+	DrawTimelineHandler(w http.ResponseWriter, r *http.Request)
+	DrawHeatmapHandler(w http.ResponseWriter, r *http.Request)
+	DrawResponseTimesHandler(w http.ResponseWriter, r *http.Request)
 	GetNamesHandler(w http.ResponseWriter, r *http.Request)
+	GetTypesHandler(w http.ResponseWriter, r *http.Request)
+	GetStopwordsHandler(w http.ResponseWriter, r *http.Request)
+	GetReactionsHandler(w http.ResponseWriter, r *http.Request)
+	GetInteractionsHandler(w http.ResponseWriter, r *http.Request)
+	GetLinksHandler(w http.ResponseWriter, r *http.Request)
+	DrawMediaHandler(w http.ResponseWriter, r *http.Request)
+	DrawCallsHandler(w http.ResponseWriter, r *http.Request)
+	GetMembershipHandler(w http.ResponseWriter, r *http.Request)
+	GetMetricsHandler(w http.ResponseWriter, r *http.Request)
+	DrawInteractionsHandler(w http.ResponseWriter, r *http.Request)
 	TopStickerHandler(w http.ResponseWriter, r *http.Request)
 }
 
@@ -54,21 +75,61 @@ func (c client) DrawBarGraphHandler(w http.ResponseWriter, r *http.Request) {
 
 	count, err := strconv.Atoi(countStr)
 	if err != nil {
-		fmt.Println("failed to parse count: %v", err)
+		fmt.Printf("failed to parse count: %v\n", err)
 		WriteErrorResponse(w, errors.Wrap(err, "failed to parse count"))
 		return
 	}
 
-	if name != "everyone" {
-		if _, ok := c.SortedAnalysis.SortedParticipantAnalyses[name]; !ok {
-			fmt.Printf("invalid name")
-			WriteErrorResponse(w, errors.New("invalid name"))
+	name, err = c.resolveName(name)
+	if err != nil {
+		fmt.Printf("invalid name")
+		WriteErrorResponse(w, err)
+		return
+	}
+
+	if queryType == "distinctive" && name == "everyone" {
+		fmt.Printf("distinctive needs a name")
+		WriteErrorResponse(w, errors.New("distinctive words compare a participant to everyone else, choose a name"))
+		return
+	}
+
+	if n, ok := fixedNGramTypes[queryType]; ok && !c.SortedAnalysis.HasNGramSize(n) {
+		fmt.Printf("%s were not counted\n", queryType)
+		WriteErrorResponse(w, errors.Errorf("%s were not counted, counted sizes are %v", queryType, c.SortedAnalysis.NGramSizes))
+		return
+	}
+
+	bars := c.GetValuesFromQuery(name, queryType, count)
+	if queryType == "phrases" && query.Get("n") != "" {
+		n, err := strconv.Atoi(query.Get("n"))
+		if err != nil {
+			fmt.Printf("failed to parse n: %v\n", err)
+			WriteErrorResponse(w, errors.Wrap(err, "failed to parse n"))
+			return
+		}
+		if !c.SortedAnalysis.HasNGramSize(n) {
+			fmt.Printf("%d-word phrases were not counted\n", n)
+			WriteErrorResponse(w, errors.Errorf("%d-word phrases were not counted, counted sizes are %v", n, c.SortedAnalysis.NGramSizes))
 			return
 		}
+		queryType = strconv.Itoa(n) + "-word phrases"
+		bars = c.GetPhraseValues(name, n, count)
+	}
+
+	DrawBarChart(w, GetGraphTitle(c.SortedAnalysis.DisplayName(name), queryType, countStr), bars)
+}
+
+// DrawBarChart renders the bars as a PNG bar chart to the response
+func DrawBarChart(w http.ResponseWriter, title string, bars []chart.Value) {
+	max := 1.0
+	for _, b := range bars {
+		if b.Value > max {
+			max = b.Value
+		}
 	}
 
 	bc := chart.BarChart{
-		Title:      GetGraphTitle(name, queryType, countStr),
+		Title:      title,
 		TitleStyle: chart.StyleShow(),
 		Background: chart.Style{
 			Padding: chart.Box{
@@ -85,19 +146,38 @@ func (c client) DrawBarGraphHandler(w http.ResponseWriter, r *http.Request) {
 			Style: chart.Style{
 				Show: true,
 			},
+			Range: &chart.ContinuousRange{
+				Min: 0,
+				Max: max,
+			},
 		},
-		Bars: c.GetValuesFromQuery(name, queryType, count),
+		Bars: bars,
 	}
 
 	w.Header().Set("Content-Type", "image/png")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	err = bc.Render(chart.PNG, w)
+	err := bc.Render(chart.PNG, w)
 
 	if err != nil {
-		fmt.Printf("Error rendering pie chart: %v\n", err)
+		fmt.Printf("Error rendering bar chart: %v\n", err)
 	}
 }
 
+// resolveName returns the participant the name query refers to, which
+// may be a full name, a display name or an alias
+func (c client) resolveName(name string) (string, error) {
+	if name == "everyone" {
+		return name, nil
+	}
+
+	resolved, ok := c.SortedAnalysis.ResolveName(name)
+	if !ok {
+		return "", errors.New("invalid name")
+	}
+
+	return resolved, nil
+}
+
 // GetGraphTitle gets the graph title
 func GetGraphTitle(name string, queryType string, count string) string {
 	return "Top " + count + " " + queryType + " for " + name
@@ -107,6 +187,19 @@ func GetGraphTitle(name string, queryType string, count string) string {
 func (c client) GetValuesFromQuery(name string, queryType string, count int) []chart.Value {
 	values := []chart.Value{}
 
+	switch queryType {
+	case "phrases":
+		return c.GetPhraseValues(name, 0, count)
+	case "bigrams":
+		return c.GetPhraseValues(name, 2, count)
+	case "trigrams":
+		return c.GetPhraseValues(name, 3, count)
+	case "received":
+		values = c.receivedValues(name)
+	case "mentioned":
+		values = c.mentionedValues(name)
+	}
+
 	if name == "everyone" {
 		switch queryType {
 		case "words":
@@ -125,6 +218,26 @@ func (c client) GetValuesFromQuery(name string, queryType string, count int) []c
 			for _, v := range c.SortedAnalysis.Reactions {
 				values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
 			}
+		case "emojis":
+			for _, v := range c.SortedAnalysis.Emojis {
+				values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
+			}
+		case "starters":
+			for _, v := range c.SortedAnalysis.Sessions.Starters {
+				values = append(values, chart.Value{Value: float64(v.Freq), Label: c.SortedAnalysis.DisplayName(v.Value)})
+			}
+		case "enders":
+			for _, v := range c.SortedAnalysis.Sessions.Enders {
+				values = append(values, chart.Value{Value: float64(v.Freq), Label: c.SortedAnalysis.DisplayName(v.Value)})
+			}
+		case "unresolved":
+			for _, v := range c.SortedAnalysis.UnresolvedMentions {
+				values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
+			}
+		case "links":
+			for _, v := range c.SortedAnalysis.Domains {
+				values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
+			}
 		}
 	} else {
 		switch queryType {
@@ -144,6 +257,22 @@ func (c client) GetValuesFromQuery(name string, queryType string, count int) []c
 			for _, v := range c.SortedAnalysis.SortedParticipantAnalyses[name].Reactions {
 				values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
 			}
+		case "emojis":
+			for _, v := range c.SortedAnalysis.SortedParticipantAnalyses[name].Emojis {
+				values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
+			}
+		case "unresolved":
+			for _, v := range c.SortedAnalysis.SortedParticipantAnalyses[name].UnresolvedMentions {
+				values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
+			}
+		case "links":
+			for _, v := range c.SortedAnalysis.SortedParticipantAnalyses[name].Domains {
+				values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
+			}
+		case "distinctive":
+			for _, v := range c.SortedAnalysis.SortedParticipantAnalyses[name].Distinctive {
+				values = append(values, chart.Value{Value: v.Score, Label: v.Word})
+			}
 		}
 	}
 	if len(values) > count {
@@ -152,6 +281,53 @@ func (c client) GetValuesFromQuery(name string, queryType string, count int) []c
 	return values
 }
 
+// GetPhraseValues gets the values of the phrases with n words for the bar
+// graph, or of phrases of every length when n is zero
+func (c client) GetPhraseValues(name string, n int, count int) []chart.Value {
+	phrases := c.SortedAnalysis.Phrases
+	if name != "everyone" {
+		phrases = c.SortedAnalysis.SortedParticipantAnalyses[name].Phrases
+	}
+
+	sfs := phrases[n]
+	if n == 0 {
+		sfs = message.AllPhrases(phrases)
+	}
+
+	values := []chart.Value{}
+	for _, v := range sfs {
+		values = append(values, chart.Value{Value: float64(v.Freq), Label: v.Value})
+	}
+	if len(values) > count {
+		return values[:count]
+	}
+	return values
+}
+
+// mentionedValues gets the values of the mentioned graph type, how often
+// each participant is mentioned or, for a name, whom they mention
+func (c client) mentionedValues(name string) []chart.Value {
+	mentioned := message.StringFreqs{}
+	if name == "everyone" {
+		counts := make(map[string]int)
+		for _, pa := range c.SortedAnalysis.SortedParticipantAnalyses {
+			for _, v := range pa.MentionsOf {
+				counts[v.Value] += v.Freq
+			}
+		}
+		mentioned = message.MapToSortedStringFreqs(counts)
+	} else {
+		mentioned = c.SortedAnalysis.SortedParticipantAnalyses[name].MentionsOf
+	}
+
+	values := []chart.Value{}
+	for _, v := range mentioned {
+		values = append(values, chart.Value{Value: float64(v.Freq), Label: c.SortedAnalysis.DisplayName(v.Value)})
+	}
+
+	return values
+}
+
 func (c client) TopStickerHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	if _, ok := query["name"]; !ok {
@@ -172,12 +348,11 @@ func (c client) TopStickerHandler(w http.ResponseWriter, r *http.Request) {
 
 	name := query["name"][0]
 
-	if name != "everyone" {
-		if _, ok := c.SortedAnalysis.SortedParticipantAnalyses[name]; !ok {
-			fmt.Printf("invalid name")
-			WriteErrorResponse(w, errors.New("invalid name"))
-			return
-		}
+	name, err = c.resolveName(name)
+	if err != nil {
+		fmt.Printf("invalid name")
+		WriteErrorResponse(w, err)
+		return
 	}
 
 	var stickerID string
@@ -194,14 +369,51 @@ func (c client) TopStickerHandler(w http.ResponseWriter, r *http.Request) {
 func (c client) GetNamesHandler(w http.ResponseWriter, r *http.Request) {
 	names := []string{"everyone"}
 
-	for k := range c.SortedAnalysis.SortedParticipantAnalyses {
-		names = append(names, k)
+	for _, name := range c.SortedAnalysis.Participants {
+		names = append(names, name)
 	}
 
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	WriteJSONResponse(w, names)
 }
 
+func (c client) GetTypesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	WriteJSONResponse(w, GraphTypes)
+}
+
+// GetLinksHandler returns the count most shared links, 10 by default, with
+// when and by whom each was first shared
+func (c client) GetLinksHandler(w http.ResponseWriter, r *http.Request) {
+	count := 10
+	if countStr := r.URL.Query().Get("count"); countStr != "" {
+		var err error
+		count, err = strconv.Atoi(countStr)
+		if err != nil {
+			fmt.Printf("failed to parse count: %v\n", err)
+			WriteErrorResponse(w, errors.Wrap(err, "failed to parse count"))
+			return
+		}
+	}
+
+	links := []message.SharedLink{}
+	for i, link := range c.SortedAnalysis.Links {
+		if i >= count {
+			break
+		}
+		link.FirstSharedBy = c.SortedAnalysis.DisplayName(link.FirstSharedBy)
+		links = append(links, link)
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	WriteJSONResponse(w, links)
+}
+
+func (c client) GetStopwordsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	WriteJSONResponse(w, c.SortedAnalysis.Stopwords)
+}
+
 // WriteErrorResponse writes an error back from an invalid request
 func WriteErrorResponse(w http.ResponseWriter, err error) {
 	http.Error(w, err.Error(), http.StatusBadRequest)